@@ -0,0 +1,121 @@
+package mdlib
+
+import "context"
+
+// ManagedDeliveryAPI is the set of typed Spinnaker API calls used to discover and export
+// application resources.  *Client implements this interface, it can be mocked in unit
+// tests for code that depends on the Spinnaker API.
+type ManagedDeliveryAPI interface {
+	ServerGroups(ctx context.Context, appName string) ([]ServerGroup, error)
+	LoadBalancers(ctx context.Context, appName string) ([]LoadBalancer, error)
+	SecurityGroups(ctx context.Context, appName string) ([]SecurityGroup, error)
+	Credential(ctx context.Context, account string) (*Credential, error)
+	ExportResource(ctx context.Context, resource *ExportableResource) ([]byte, error)
+	ExportArtifact(ctx context.Context, resource *ExportableResource) (*DeliveryArtifact, error)
+}
+
+var _ ManagedDeliveryAPI = (*Client)(nil)
+
+// ManifestAPI is implemented by a ManagedDeliveryAPI that can get kubernetes manifests,
+// it is required to export kubernetes resources.  *Client implements this interface.
+type ManifestAPI interface {
+	Manifest(ctx context.Context, account, namespace, name string) (*Manifest, error)
+}
+
+var _ ManifestAPI = (*Client)(nil)
+
+// ServerGroups returns the server groups for spinnaker application appName.
+func (c *Client) ServerGroups(ctx context.Context, appName string) ([]ServerGroup, error) {
+	return ServerGroupsAs[ServerGroup](ctx, c, appName)
+}
+
+// LoadBalancers returns the load balancers for spinnaker application appName.
+func (c *Client) LoadBalancers(ctx context.Context, appName string) ([]LoadBalancer, error) {
+	return LoadBalancersAs[LoadBalancer](ctx, c, appName)
+}
+
+// SecurityGroups returns the security groups found for spinnaker application appName.
+func (c *Client) SecurityGroups(ctx context.Context, appName string) ([]SecurityGroup, error) {
+	return SecurityGroupsAs[SecurityGroup](ctx, c, appName)
+}
+
+// Credential returns the credential details for the spinnaker account.
+func (c *Client) Credential(ctx context.Context, account string) (*Credential, error) {
+	return CredentialAs[Credential](ctx, c, account)
+}
+
+// Manifest returns the kubernetes manifest name, like `deployment myapp`, in the spinnaker
+// account and namespace.
+func (c *Client) Manifest(ctx context.Context, account, namespace, name string) (*Manifest, error) {
+	return ManifestAs[Manifest](ctx, c, account, namespace, name)
+}
+
+// ExportResource returns the YAML delivery config representation for a specific resource.
+func (c *Client) ExportResource(ctx context.Context, resource *ExportableResource) ([]byte, error) {
+	return ExportResourceContext(ctx, c, resource)
+}
+
+// ExportArtifact returns the delivery artifact for the given cluster.
+func (c *Client) ExportArtifact(ctx context.Context, resource *ExportableResource) (*DeliveryArtifact, error) {
+	artifact := &DeliveryArtifact{}
+	err := ExportArtifactContext(ctx, c, resource, artifact)
+	if err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// ServerGroupsAs returns the server groups for spinnaker application appName decoded as
+// a custom result type T.
+func ServerGroupsAs[T any](ctx context.Context, cli *Client, appName string) ([]T, error) {
+	result := []T{}
+	err := GetServerGroupsContext(ctx, cli, appName, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// LoadBalancersAs returns the load balancers for spinnaker application appName decoded as
+// a custom result type T.
+func LoadBalancersAs[T any](ctx context.Context, cli *Client, appName string) ([]T, error) {
+	result := []T{}
+	err := GetLoadBalancersContext(ctx, cli, appName, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SecurityGroupsAs returns the security groups found for spinnaker application appName
+// decoded as a custom result type T.
+func SecurityGroupsAs[T any](ctx context.Context, cli *Client, appName string) ([]T, error) {
+	result := []T{}
+	err := GetSecurityGroupsContext(ctx, cli, appName, &result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CredentialAs returns the credential details for the spinnaker account decoded as a
+// custom result type T.
+func CredentialAs[T any](ctx context.Context, cli *Client, account string) (*T, error) {
+	result := new(T)
+	err := GetCredentialContext(ctx, cli, account, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ManifestAs returns the kubernetes manifest name in the spinnaker account and namespace
+// decoded as a custom result type T.
+func ManifestAs[T any](ctx context.Context, cli *Client, account, namespace, name string) (*T, error) {
+	result := new(T)
+	err := GetManifestContext(ctx, cli, account, namespace, name, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
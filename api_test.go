@@ -0,0 +1,68 @@
+package mdlib
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAPI struct {
+	ManagedDeliveryAPI
+	serverGroups   []ServerGroup
+	loadBalancers  []LoadBalancer
+	securityGroups []SecurityGroup
+	manifests      map[string]*Manifest
+}
+
+func (f *fakeAPI) ServerGroups(context.Context, string) ([]ServerGroup, error) {
+	return f.serverGroups, nil
+}
+
+func (f *fakeAPI) LoadBalancers(context.Context, string) ([]LoadBalancer, error) {
+	return f.loadBalancers, nil
+}
+
+func (f *fakeAPI) SecurityGroups(context.Context, string) ([]SecurityGroup, error) {
+	return f.securityGroups, nil
+}
+
+func (f *fakeAPI) Manifest(_ context.Context, account, namespace, name string) (*Manifest, error) {
+	manifest, ok := f.manifests[fmt.Sprintf("%s/%s/%s", account, namespace, name)]
+	if !ok {
+		return nil, ErrorUnexpectedResponse{StatusCode: 404}
+	}
+	return manifest, nil
+}
+
+func TestFindApplicationResourcesFromAPI(t *testing.T) {
+	api := &fakeAPI{
+		serverGroups: []ServerGroup{
+			{Account: "test", Type: AWSCloudProvider, Moniker: Moniker{App: "myapp", Cluster: "myapp"}},
+		},
+		loadBalancers: []LoadBalancer{
+			{Name: "myapp-frontend", Account: "test", Type: AWSCloudProvider, TargetGroups: []LoadBalancerTargetGroup{{Name: "myapp-tg"}}},
+			{Name: "myapp-nlb", Account: "test", Type: AWSCloudProvider, LoadBalancerType: "network"},
+			{Name: "myapp-elb", Account: "test", Type: AWSCloudProvider},
+			{Name: "otherapp", Account: "test", Type: AWSCloudProvider},
+		},
+		securityGroups: []SecurityGroup{
+			{Name: "myapp", Account: "test", Region: "us-east-1"},
+		},
+	}
+
+	appData, err := FindApplicationResourcesFromAPI(context.Background(), api, "myapp")
+	require.NoError(t, err)
+
+	exportable := ExportableApplicationResources(appData)
+	sort.Sort(ResourceSorter(exportable))
+	require.Equal(t, []*ExportableResource{
+		{ResourceType: ApplicationLoadBalancerResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp-frontend"},
+		{ResourceType: LoadBalancerResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp-elb"},
+		{ResourceType: ClusterResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp"},
+		{ResourceType: NetworkLoadBalancerResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp-nlb"},
+		{ResourceType: SecurityGroupResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp"},
+	}, exportable)
+}
@@ -0,0 +1,93 @@
+package mdlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"golang.org/x/xerrors"
+)
+
+// EnvironmentArtifactVersion identifies a version of a delivery artifact in an
+// environment, it is the request body for pinning and vetoing artifact versions.
+type EnvironmentArtifactVersion struct {
+	// TargetEnvironment is the name of the environment from the delivery config
+	TargetEnvironment string `json:"targetEnvironment" yaml:"targetEnvironment"`
+	// Reference is the artifact reference from the delivery config, see DeliveryArtifact.RefName
+	Reference string `json:"reference" yaml:"reference"`
+	Version   string `json:"version" yaml:"version"`
+	Comment   string `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+// PinArtifactVersion will cause Spinnaker to deploy only the pinned version of the
+// artifact to the target environment until it is unpinned.
+func PinArtifactVersion(cli *Client, appName string, pin EnvironmentArtifactVersion) error {
+	return PinArtifactVersionContext(context.Background(), cli, appName, pin)
+}
+
+// PinArtifactVersionContext is like PinArtifactVersion but the request is bound to ctx.
+func PinArtifactVersionContext(ctx context.Context, cli *Client, appName string, pin EnvironmentArtifactVersion) error {
+	return postArtifactVersion(ctx, cli, fmt.Sprintf("/managed/application/%s/pin", url.PathEscape(appName)), pin)
+}
+
+// UnpinArtifactVersion will remove the pin for the artifact in the target environment.
+func UnpinArtifactVersion(cli *Client, appName, envName, reference string) error {
+	return UnpinArtifactVersionContext(context.Background(), cli, appName, envName, reference)
+}
+
+// UnpinArtifactVersionContext is like UnpinArtifactVersion but the request is bound to ctx.
+func UnpinArtifactVersionContext(ctx context.Context, cli *Client, appName, envName, reference string) error {
+	u := fmt.Sprintf(
+		"/managed/application/%s/pin/%s?reference=%s",
+		url.PathEscape(appName), url.PathEscape(envName), url.QueryEscape(reference),
+	)
+	_, err := commonRequest(ctx, cli, "DELETE", u, requestBody{})
+	return err
+}
+
+// VetoArtifactVersion will prevent the artifact version from being deployed to the target
+// environment, if it is currently deployed Spinnaker will roll back to the previous version.
+func VetoArtifactVersion(cli *Client, appName string, veto EnvironmentArtifactVersion) error {
+	return VetoArtifactVersionContext(context.Background(), cli, appName, veto)
+}
+
+// VetoArtifactVersionContext is like VetoArtifactVersion but the request is bound to ctx.
+func VetoArtifactVersionContext(ctx context.Context, cli *Client, appName string, veto EnvironmentArtifactVersion) error {
+	return postArtifactVersion(ctx, cli, fmt.Sprintf("/managed/application/%s/veto", url.PathEscape(appName)), veto)
+}
+
+// MarkArtifactVersionBad will mark the artifact version as bad in the target environment,
+// which vetoes the version so it will not be deployed again.
+func MarkArtifactVersionBad(cli *Client, appName string, version EnvironmentArtifactVersion) error {
+	return MarkArtifactVersionBadContext(context.Background(), cli, appName, version)
+}
+
+// MarkArtifactVersionBadContext is like MarkArtifactVersionBad but the request is bound to ctx.
+func MarkArtifactVersionBadContext(ctx context.Context, cli *Client, appName string, version EnvironmentArtifactVersion) error {
+	return postArtifactVersion(ctx, cli, fmt.Sprintf("/managed/application/%s/mark/bad", url.PathEscape(appName)), version)
+}
+
+// MarkArtifactVersionGood will remove a veto or bad mark for the artifact version in the
+// target environment, so it can be deployed again.
+func MarkArtifactVersionGood(cli *Client, appName string, version EnvironmentArtifactVersion) error {
+	return MarkArtifactVersionGoodContext(context.Background(), cli, appName, version)
+}
+
+// MarkArtifactVersionGoodContext is like MarkArtifactVersionGood but the request is bound to ctx.
+func MarkArtifactVersionGoodContext(ctx context.Context, cli *Client, appName string, version EnvironmentArtifactVersion) error {
+	return postArtifactVersion(ctx, cli, fmt.Sprintf("/managed/application/%s/mark/good", url.PathEscape(appName)), version)
+}
+
+func postArtifactVersion(ctx context.Context, cli *Client, u string, version EnvironmentArtifactVersion) error {
+	content, err := json.Marshal(version)
+	if err != nil {
+		return xerrors.Errorf("failed to encode artifact version: %w", err)
+	}
+	_, err = commonRequest(ctx, cli, "POST", u, requestBody{
+		Content:     bytes.NewReader(content),
+		ContentType: "application/json",
+	})
+	return err
+}
@@ -0,0 +1,86 @@
+package mdlib
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/xerrors"
+)
+
+// Authenticator adds credentials to each request sent to the Spinnaker API.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFunc is an adapter to allow the use of ordinary functions as an Authenticator.
+type AuthenticatorFunc func(req *http.Request) error
+
+// Authenticate calls f(req)
+func (f AuthenticatorFunc) Authenticate(req *http.Request) error {
+	return f(req)
+}
+
+// WithAuthenticator is a ClientOpt to add an Authenticator via NewClient.  Authenticators
+// are applied to each request in the order they were added.
+func WithAuthenticator(a Authenticator) ClientOpt {
+	return func(c *Client) {
+		c.authenticators = append(c.authenticators, a)
+	}
+}
+
+// WithBearerToken is a ClientOpt to send a static bearer token in the Authorization header.
+func WithBearerToken(token string) ClientOpt {
+	return WithAuthenticator(AuthenticatorFunc(func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}))
+}
+
+// WithBasicAuth is a ClientOpt to authenticate with a username and password.
+func WithBasicAuth(username, password string) ClientOpt {
+	return WithAuthenticator(AuthenticatorFunc(func(req *http.Request) error {
+		req.SetBasicAuth(username, password)
+		return nil
+	}))
+}
+
+// WithOAuth2TokenSource is a ClientOpt to authenticate with OAuth2 access tokens.  Tokens are
+// cached and only refreshed from the TokenSource once they expire.
+func WithOAuth2TokenSource(src oauth2.TokenSource) ClientOpt {
+	src = oauth2.ReuseTokenSource(nil, src)
+	return WithAuthenticator(AuthenticatorFunc(func(req *http.Request) error {
+		token, err := src.Token()
+		if err != nil {
+			return xerrors.Errorf("failed to get oauth2 token: %w", err)
+		}
+		token.SetAuthHeader(req)
+		return nil
+	}))
+}
+
+// DefaultSessionCookieName is the name of the cookie Gate uses to track an authenticated session.
+var DefaultSessionCookieName = "SESSION"
+
+// WithSessionCookie is a ClientOpt to reuse an existing Gate session, the value is sent
+// in the DefaultSessionCookieName cookie.
+func WithSessionCookie(value string) ClientOpt {
+	return WithAuthenticator(AuthenticatorFunc(func(req *http.Request) error {
+		req.AddCookie(&http.Cookie{Name: DefaultSessionCookieName, Value: value})
+		return nil
+	}))
+}
+
+// WithClientCertificate is a ClientOpt to authenticate with an x509 client certificate.  The
+// certificate is added to the TLS configuration of the default transport, so requests fail
+// when it is combined with WithHTTPClient, use ApplyTransportOptions with ClientCertificate
+// on the custom client instead.
+func WithClientCertificate(cert tls.Certificate) ClientOpt {
+	return WithTransport(ClientCertificate(cert))
+}
+
+// WithClientCertificateFiles is like WithClientCertificate but loads the PEM encoded
+// certificate and key from disk.
+func WithClientCertificateFiles(certFile, keyFile string) ClientOpt {
+	return WithTransport(ClientCertificateFiles(certFile, keyFile))
+}
@@ -0,0 +1,165 @@
+package mdlib
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// authServer returns a server that records the request headers and responds with an
+// empty list of server groups.
+func authServer(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				*requests = append(*requests, r)
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[]`))
+			},
+		),
+	)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestBearerToken(t *testing.T) {
+	requests := []*http.Request{}
+	ts := authServer(t, &requests)
+
+	cli := NewClient(WithBaseURL(ts.URL), WithBearerToken("s3cr3t"))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.Len(t, requests, 1)
+	require.Equal(t, "Bearer s3cr3t", requests[0].Header.Get("Authorization"))
+}
+
+func TestBasicAuth(t *testing.T) {
+	requests := []*http.Request{}
+	ts := authServer(t, &requests)
+
+	cli := NewClient(WithBaseURL(ts.URL), WithBasicAuth("user", "pass"))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.Len(t, requests, 1)
+	username, password, ok := requests[0].BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", username)
+	require.Equal(t, "pass", password)
+}
+
+type countingTokenSource struct {
+	count  int
+	expiry time.Time
+}
+
+func (s *countingTokenSource) Token() (*oauth2.Token, error) {
+	s.count++
+	return &oauth2.Token{
+		AccessToken: fmt.Sprintf("token-%d", s.count),
+		TokenType:   "Bearer",
+		Expiry:      s.expiry,
+	}, nil
+}
+
+func TestOAuth2TokenSource(t *testing.T) {
+	requests := []*http.Request{}
+	ts := authServer(t, &requests)
+
+	// valid tokens are reused
+	src := &countingTokenSource{expiry: time.Now().Add(time.Hour)}
+	cli := NewClient(WithBaseURL(ts.URL), WithOAuth2TokenSource(src))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.Equal(t, 1, src.count)
+	require.Equal(t, "Bearer token-1", requests[0].Header.Get("Authorization"))
+	require.Equal(t, "Bearer token-1", requests[1].Header.Get("Authorization"))
+
+	// expired tokens are refreshed
+	requests = requests[:0]
+	src = &countingTokenSource{expiry: time.Now().Add(-time.Minute)}
+	cli = NewClient(WithBaseURL(ts.URL), WithOAuth2TokenSource(src))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.Equal(t, 2, src.count)
+	require.Equal(t, "Bearer token-1", requests[0].Header.Get("Authorization"))
+	require.Equal(t, "Bearer token-2", requests[1].Header.Get("Authorization"))
+}
+
+func TestSessionCookie(t *testing.T) {
+	requests := []*http.Request{}
+	ts := authServer(t, &requests)
+
+	cli := NewClient(WithBaseURL(ts.URL), WithSessionCookie("abc123"))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+	require.Len(t, requests, 1)
+	cookie, err := requests[0].Cookie(DefaultSessionCookieName)
+	require.NoError(t, err)
+	require.Equal(t, "abc123", cookie.Value)
+}
+
+// clientCertificate returns a self signed certificate for client authentication.
+func clientCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "spinmd"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestClientCertificate(t *testing.T) {
+	cert := clientCertificate(t)
+
+	ts := httptest.NewUnstartedServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"name": "` + r.TLS.PeerCertificates[0].Subject.CommonName + `"}]`))
+			},
+		),
+	)
+	ts.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	ts.StartTLS()
+	defer ts.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	// the server requires a client certificate
+	cli := NewClient(WithBaseURL(ts.URL), WithTransport(CACertificates(caPEM)), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	require.Error(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+
+	cli = NewClient(WithBaseURL(ts.URL), WithTransport(CACertificates(caPEM)), WithClientCertificate(cert))
+	serverGroups := []ServerGroup{}
+	require.NoError(t, GetServerGroups(cli, "myapp", &serverGroups))
+	require.Equal(t, []ServerGroup{{Name: "spinmd"}}, serverGroups)
+
+	// the certificate cannot be added to a custom client, so that is an error
+	// instead of silently sending requests without it
+	cli = NewClient(WithBaseURL(ts.URL), WithHTTPClient(http.DefaultClient.Do), WithClientCertificate(cert))
+	err := GetServerGroups(cli, "myapp", &[]ServerGroup{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot be combined with WithHTTPClient")
+
+	// the certificate can be applied to the custom client instead
+	client, err := ApplyTransportOptions(&http.Client{}, CACertificates(caPEM), ClientCertificate(cert))
+	require.NoError(t, err)
+	cli = NewClient(WithBaseURL(ts.URL), WithHTTPClient(client.Do))
+	require.NoError(t, GetServerGroups(cli, "myapp", &[]ServerGroup{}))
+}
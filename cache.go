@@ -0,0 +1,248 @@
+package mdlib
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// CachedResponse is a response body stored in a ResponseCache along with the ETag used to
+// revalidate it.
+type CachedResponse struct {
+	ETag        string `json:"etag"`
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content"`
+}
+
+// ResponseCache stores responses for GET requests keyed by URL.
+type ResponseCache interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, resp *CachedResponse) error
+}
+
+// WithResponseCache is a ClientOpt to cache GET responses that have an ETag header.  Cached
+// responses are revalidated with an If-None-Match header on each request, so stale content is
+// never returned.
+func WithResponseCache(cache ResponseCache) ClientOpt {
+	return WithMiddleware(ETagMiddleware(cache))
+}
+
+// ETagMiddleware will send If-None-Match headers for GET requests with a cached response, and
+// replace 304 Not Modified responses with the cached content.
+func ETagMiddleware(cache ResponseCache) Middleware {
+	return func(next Doer) Doer {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodGet {
+				return next(req)
+			}
+			key := req.URL.String()
+			cached, ok := cache.Get(key)
+			if ok && cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			resp, err := next(req)
+			if err != nil {
+				return resp, err
+			}
+
+			switch {
+			case resp.StatusCode == http.StatusNotModified && ok:
+				resp.Body.Close()
+				resp.StatusCode = http.StatusOK
+				resp.Status = "200 OK"
+				if cached.ContentType != "" {
+					resp.Header.Set("Content-Type", cached.ContentType)
+				}
+				resp.ContentLength = int64(len(cached.Content))
+				resp.Body = ioutil.NopCloser(bytes.NewReader(cached.Content))
+			case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+				content, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					return nil, xerrors.Errorf("failed to read response body from %s: %w", key, err)
+				}
+				resp.Body = ioutil.NopCloser(bytes.NewReader(content))
+				// failing to cache is not fatal, we will just fetch it again next time
+				cache.Set(key, &CachedResponse{
+					ETag:        resp.Header.Get("ETag"),
+					ContentType: resp.Header.Get("Content-Type"),
+					Content:     content,
+				})
+			}
+			return resp, nil
+		}
+	}
+}
+
+type memoryResponseCache struct {
+	mu        sync.Mutex
+	responses map[string]*CachedResponse
+}
+
+// NewMemoryResponseCache returns a ResponseCache that holds responses in memory.
+func NewMemoryResponseCache() ResponseCache {
+	return &memoryResponseCache{responses: map[string]*CachedResponse{}}
+}
+
+func (c *memoryResponseCache) Get(key string) (*CachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.responses[key]
+	return resp, ok
+}
+
+func (c *memoryResponseCache) Set(key string, resp *CachedResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[key] = resp
+	return nil
+}
+
+type diskResponseCache struct {
+	dir string
+}
+
+// NewDiskResponseCache returns a ResponseCache that stores responses as files in dir.
+func NewDiskResponseCache(dir string) ResponseCache {
+	return &diskResponseCache{dir: dir}
+}
+
+func (c *diskResponseCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *diskResponseCache) Get(key string) (*CachedResponse, bool) {
+	content, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	resp := &CachedResponse{}
+	if err := json.Unmarshal(content, resp); err != nil {
+		return nil, false
+	}
+	return resp, true
+}
+
+func (c *diskResponseCache) Set(key string, resp *CachedResponse) error {
+	content, err := json.Marshal(resp)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal cached response: %w", err)
+	}
+	return writeCacheFile(c.path(key), content)
+}
+
+// writeCacheFile will atomically write content to path, creating the parent directory if needed.
+func writeCacheFile(path string, content []byte) error {
+	dir := filepath.Dir(path)
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return xerrors.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := ioutil.TempFile(dir, ".tmp-")
+	if err != nil {
+		return xerrors.Errorf("failed to create cache file in %s: %w", dir, err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to write cache file %s: %w", tmp.Name(), err)
+	}
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return xerrors.Errorf("failed to write cache file %s: %w", path, err)
+	}
+	return nil
+}
+
+// ApplicationResourcesCache stores the results of FindApplicationResources on disk so repeated
+// exports of large applications do not need to load all the resources again.
+type ApplicationResourcesCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewApplicationResourcesCache returns an ApplicationResourcesCache that stores results in dir.
+// Results older than ttl are ignored.
+func NewApplicationResourcesCache(dir string, ttl time.Duration) *ApplicationResourcesCache {
+	return &ApplicationResourcesCache{dir: dir, ttl: ttl}
+}
+
+type cachedApplicationResources struct {
+	StoredAt  time.Time             `json:"storedAt"`
+	Resources *ApplicationResources `json:"resources"`
+}
+
+func (c *ApplicationResourcesCache) path(baseURL, appName string) string {
+	// hash the base url with the app name so we do not mix up apps from different spinnaker
+	// installations, the escaped app name is only there to make the files recognizable
+	sum := sha256.Sum256([]byte(baseURL + "\n" + appName))
+	return filepath.Join(c.dir, url.PathEscape(appName)+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// Get returns the cached resources for appName from the spinnaker installation at baseURL
+// if present and not expired.
+func (c *ApplicationResourcesCache) Get(baseURL, appName string) (*ApplicationResources, bool) {
+	data, _, ok := c.Lookup(baseURL, appName)
+	return data, ok
+}
+
+// Lookup is like Get but also returns when the cached resources were stored.
+func (c *ApplicationResourcesCache) Lookup(baseURL, appName string) (*ApplicationResources, time.Time, bool) {
+	content, err := ioutil.ReadFile(c.path(baseURL, appName))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	cached := cachedApplicationResources{}
+	if err := json.Unmarshal(content, &cached); err != nil || cached.Resources == nil {
+		return nil, time.Time{}, false
+	}
+	if time.Since(cached.StoredAt) > c.ttl {
+		return nil, time.Time{}, false
+	}
+	return cached.Resources, cached.StoredAt, true
+}
+
+// Put stores the resources for the spinnaker installation at baseURL.
+func (c *ApplicationResourcesCache) Put(baseURL string, data *ApplicationResources) error {
+	content, err := json.Marshal(cachedApplicationResources{
+		StoredAt:  time.Now(),
+		Resources: data,
+	})
+	if err != nil {
+		return xerrors.Errorf("failed to marshal application resources: %w", err)
+	}
+	return writeCacheFile(c.path(baseURL, data.AppName), content)
+}
+
+// FindApplicationResourcesCached is like FindApplicationResourcesContext but will return
+// results from the cache when available.  When refresh is true the cached results are ignored
+// and the cache is updated with newly loaded resources.
+func FindApplicationResourcesCached(ctx context.Context, cli *Client, appName string, cache *ApplicationResourcesCache, refresh bool) (*ApplicationResources, error) {
+	if !refresh {
+		if data, ok := cache.Get(cli.spinnakerAPIBaseURL, appName); ok {
+			return data, nil
+		}
+	}
+	data, err := FindApplicationResourcesContext(ctx, cli, appName)
+	if err != nil {
+		return nil, err
+	}
+	// failing to cache is not fatal, we will just load the resources again next time
+	cache.Put(cli.spinnakerAPIBaseURL, data)
+	return data, nil
+}
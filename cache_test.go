@@ -0,0 +1,78 @@
+package mdlib
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCacheETag(t *testing.T) {
+	responses := []int{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					responses = append(responses, http.StatusNotModified)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				responses = append(responses, http.StatusOK)
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("ETag", `"v1"`)
+				w.Write([]byte(`[{"name": "myapp-v001", "account": "test"}]`))
+			},
+		),
+	)
+	defer ts.Close()
+
+	tdir, err := ioutil.TempDir("", "mdlib-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(tdir)
+
+	for i := 0; i < 2; i++ {
+		// new client each time to ensure the cache is loaded from disk
+		cli := NewClient(WithBaseURL(ts.URL), WithResponseCache(NewDiskResponseCache(tdir)))
+		serverGroups := []ServerGroup{}
+		err = GetServerGroups(cli, "myapp", &serverGroups)
+		require.NoError(t, err)
+		require.Equal(t, []ServerGroup{{Name: "myapp-v001", Account: "test"}}, serverGroups)
+	}
+	require.Equal(t, []int{http.StatusOK, http.StatusNotModified}, responses)
+}
+
+func TestApplicationResourcesCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewApplicationResourcesCache(dir, time.Hour)
+
+	for _, appName := range []string{"myapp", "../myapp", "my/app"} {
+		_, ok := cache.Get("https://gate.example.com", appName)
+		require.False(t, ok)
+
+		require.NoError(t, cache.Put("https://gate.example.com", &ApplicationResources{AppName: appName}))
+		data, storedAt, ok := cache.Lookup("https://gate.example.com", appName)
+		require.True(t, ok, appName)
+		require.Equal(t, appName, data.AppName)
+		require.WithinDuration(t, time.Now(), storedAt, time.Minute)
+
+		// other spinnaker installations are cached separately
+		_, ok = cache.Get("https://gate.other.example.com", appName)
+		require.False(t, ok)
+	}
+
+	// app names are escaped so every file stays in the cache directory
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	for _, file := range files {
+		require.False(t, file.IsDir(), file.Name())
+	}
+
+	expired := NewApplicationResourcesCache(dir, 0)
+	_, ok := expired.Get("https://gate.example.com", "myapp")
+	require.False(t, ok)
+}
@@ -1,11 +1,14 @@
 package mdlib
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"time"
 
 	"golang.org/x/xerrors"
 )
@@ -20,6 +23,14 @@ var (
 type Client struct {
 	spinnakerAPIBaseURL string
 	httpClient          func(*http.Request) (*http.Response, error)
+	customHTTPClient    bool
+	transportOpts       []TransportOption
+	requestTimeout      time.Duration
+	err                 error
+	retryPolicy         RetryPolicy
+	searchPageSize      int
+	authenticators      []Authenticator
+	middleware          []Middleware
 }
 
 // ClientOpt is an interface for variadic options when constructing a Client via NewClient
@@ -34,9 +45,30 @@ func NewClient(opts ...ClientOpt) *Client {
 	for _, opt := range opts {
 		opt(c)
 	}
+	switch {
+	case len(c.transportOpts) > 0 && c.customHTTPClient:
+		// the custom client is opaque so the TLS and proxy settings cannot be
+		// applied, fail rather than silently ignoring them
+		c.err = xerrors.New("transport options, like client certificates, cannot be combined with WithHTTPClient, use ApplyTransportOptions on the custom client instead")
+	case len(c.transportOpts) > 0:
+		client, err := ApplyTransportOptions(&http.Client{}, c.transportOpts...)
+		if err != nil {
+			c.err = xerrors.Errorf("failed to configure transport: %w", err)
+		} else {
+			c.httpClient = client.Do
+		}
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		c.httpClient = c.middleware[i](c.httpClient)
+	}
 	return c
 }
 
+// BaseURL returns the base url used for spinnaker api calls.
+func (c *Client) BaseURL() string {
+	return c.spinnakerAPIBaseURL
+}
+
 // WithBaseURL is a ClientOpt to set the spinnakerAPIBaseURL via NewClient
 func WithBaseURL(baseURL string) ClientOpt {
 	return func(c *Client) {
@@ -48,11 +80,12 @@ func WithBaseURL(baseURL string) ClientOpt {
 func WithHTTPClient(client func(*http.Request) (*http.Response, error)) ClientOpt {
 	return func(c *Client) {
 		c.httpClient = client
+		c.customHTTPClient = true
 	}
 }
 
-func commonParsedGet(cli *Client, u string, result interface{}) error {
-	content, err := commonRequest(cli, "GET", u, requestBody{})
+func commonParsedGet(ctx context.Context, cli *Client, u string, result interface{}) error {
+	content, err := commonRequest(ctx, cli, "GET", u, requestBody{})
 	if err != nil {
 		return xerrors.Errorf("failed to get content for %s: %w", u, err)
 	}
@@ -71,43 +104,98 @@ func commonParsedGet(cli *Client, u string, result interface{}) error {
 type requestBody struct {
 	Content     io.Reader
 	ContentType string
+	// SideEffectFree marks non-GET requests that are safe to retry, like
+	// posting a delivery config to the diff api.
+	SideEffectFree bool
 }
 
-func commonRequest(cli *Client, method string, u string, body requestBody) ([]byte, error) {
+func commonRequest(ctx context.Context, cli *Client, method string, u string, body requestBody) ([]byte, error) {
 	if cli.spinnakerAPIBaseURL == "" {
 		return nil, xerrors.New("SPINNAKER_API_BASE_URL environment variable not set")
 	}
+	if cli.err != nil {
+		return nil, cli.err
+	}
 	u = cli.spinnakerAPIBaseURL + u
 
-	req, err := http.NewRequest(method, u, body.Content)
+	// buffer the request body so it can be re-sent on retries
+	var payload []byte
+	if body.Content != nil {
+		var err error
+		payload, err = ioutil.ReadAll(body.Content)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read request body for %s: %w", u, err)
+		}
+	}
+
+	canRetry := cli.retryPolicy.canRetry(method, body)
+	for attempt := 1; ; attempt++ {
+		content, resp, err := doRequest(ctx, cli, method, u, body.ContentType, payload)
+		if err == nil {
+			return content, nil
+		}
+		if !canRetry || attempt >= cli.retryPolicy.MaxAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+		if resp != nil && !cli.retryPolicy.retryableStatus(resp.StatusCode) {
+			return nil, err
+		}
+
+		timer := time.NewTimer(cli.retryPolicy.backoff(attempt, resp))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, xerrors.Errorf("failed to %s %s: %w", method, u, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// doRequest makes a single attempt at the request.  The response is returned
+// along with the error when the request completed with an unexpected status.
+func doRequest(ctx context.Context, cli *Client, method, u, contentType string, payload []byte) ([]byte, *http.Response, error) {
+	if cli.requestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cli.requestTimeout)
+		defer cancel()
+	}
+
+	var content io.Reader
+	if payload != nil {
+		content = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, content)
 	if err != nil {
-		return nil, xerrors.Errorf("unable to create new request for %s: %w", u, err)
+		return nil, nil, xerrors.Errorf("unable to create new request for %s: %w", u, err)
 	}
 
 	req.Header.Set("Accept", "application/json")
-	if body.ContentType != "" {
-		req.Header.Set("Content-Type", body.ContentType)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	for _, auth := range cli.authenticators {
+		if err := auth.Authenticate(req); err != nil {
+			return nil, nil, xerrors.Errorf("failed to authenticate request for %s: %w", u, err)
+		}
 	}
 
 	resp, err := cli.httpClient(req)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to %s %s: %w", method, u, err)
+		return nil, nil, xerrors.Errorf("failed to %s %s: %w", method, u, err)
 	}
 	defer resp.Body.Close()
 
-	content, err := ioutil.ReadAll(resp.Body)
+	respContent, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, xerrors.Errorf("failed to read response body from %s: %w", u, err)
+		return nil, nil, xerrors.Errorf("failed to read response body from %s: %w", u, err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		err := ErrorUnexpectedResponse{
-			StatusCode: resp.StatusCode,
-			URL:        u,
-			Content:    content,
-		}
-		return nil, xerrors.Errorf("api request: %w", err)
+		err := newErrorUnexpectedResponse(resp.StatusCode, u, respContent)
+		return nil, resp, xerrors.Errorf("api request: %w", err)
 	}
 
-	return content, nil
+	return respContent, resp, nil
 }
@@ -1,142 +1,204 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"net/http/httputil"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"regexp"
+	"time"
 
 	mdlib "github.com/spinnaker/md-lib-go"
 	"github.com/spinnaker/md-lib-go/mdcli"
-	"github.com/spinnaker/spin/cmd/gateclient"
-	"github.com/spinnaker/spin/config"
-	"gopkg.in/yaml.v3"
-	"k8s.io/client-go/util/homedir"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/spinnaker/md-lib-go/spinconfig"
 )
 
 func main() {
-	opts := mdcli.NewCommandOptions()
+	verbose := false
+	retryPolicy := mdlib.DefaultRetryPolicy
+	cacheDir := ""
+	if userCacheDir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(userCacheDir, "spinmd")
+	}
+	cacheTTL := time.Duration(0)
+	var profileName, configDir, configFile, baseURL, publishedDir string
+	var caFile, certFile, keyFile, proxy string
+	var insecure bool
+	var timeout time.Duration
+	globalFlags := flag.NewFlagSet("", flag.ContinueOnError)
+
+	globalFlags.StringVar(&profileName, "profile", "", fmt.Sprintf("named profile from %s, defaults to $%s", spinconfig.DefaultProfilesPath(), spinconfig.ProfileEnvVar))
+	globalFlags.StringVar(&configDir, "dir", mdlib.DefaultDeliveryConfigDirName, "directory for delivery config file")
+	globalFlags.StringVar(&configFile, "file", mdlib.DefaultDeliveryConfigFileName, "delivery config file name")
+	globalFlags.StringVar(&baseURL, "baseurl", "", "base URL to reach spinnaker api, defaults to the gate endpoint from the profile or spin config")
+	globalFlags.BoolVar(&verbose, "v", false, "verbose logging for rest api requests")
+	globalFlags.IntVar(&retryPolicy.MaxAttempts, "retries", retryPolicy.MaxAttempts, "maximum attempts for rest api requests that are safe to retry, 1 disables retries")
+	globalFlags.StringVar(&cacheDir, "cache-dir", cacheDir, "directory to cache spinnaker api responses, empty disables caching")
+	globalFlags.StringVar(&publishedDir, "published-dir", "", "directory to record the last published delivery config, used to merge changes with pull, defaults to .spinnaker next to the delivery config")
+	globalFlags.DurationVar(&cacheTTL, "cache-ttl", cacheTTL, "how long application resources are cached for export, 0 disables caching them")
+	globalFlags.StringVar(&caFile, "ca-file", "", "PEM encoded CA bundle to trust in addition to the system CAs")
+	globalFlags.StringVar(&certFile, "cert-file", "", "PEM encoded client certificate for x509 authentication")
+	globalFlags.StringVar(&keyFile, "key-file", "", "PEM encoded client key for x509 authentication")
+	globalFlags.BoolVar(&insecure, "insecure", false, "skip TLS certificate verification, this is NOT secure")
+	globalFlags.StringVar(&proxy, "proxy", "", "proxy url for rest api requests, overrides HTTP_PROXY and HTTPS_PROXY")
+	globalFlags.DurationVar(&timeout, "timeout", 0, "timeout for each rest api request, 0 for no timeout")
+	globalFlags.Parse(os.Args[1:])
+	args := globalFlags.Args()
 
-	cfg := config.Config{}
+	if len(args) < 1 {
+		fmt.Printf("Usage: %s [flags] export|publish|pull|diff|pause|resume|delete|validate|fmt|plan|status|history|approve|verifications|pin|unpin|veto|mark-bad|mark-good|fake-server\n", filepath.Base(os.Args[0]))
+		fmt.Printf("Flags:\n")
+		globalFlags.PrintDefaults()
+		return
+	}
 
-	configFile := filepath.Join(homedir.HomeDir(), ".spin", "config")
-	if _, err := os.Stat(configFile); err == nil {
-		yamlFile, err := ioutil.ReadFile(configFile)
-		if err != nil {
-			log.Fatalf("Unable to read %s: %s", configFile, err)
+	if args[0] == "fake-server" {
+		// the fake server does not talk to spinnaker, so it is run before
+		// loading any profile
+		var listen, liveFile string
+		var actuate bool
+		actuationDelay := 5 * time.Second
+		fakeFlags := flag.NewFlagSet("fake-server", flag.ExitOnError)
+		fakeFlags.StringVar(&listen, "listen", "127.0.0.1:8084", "address to listen on")
+		fakeFlags.StringVar(&liveFile, "live", "", "delivery config file with the resources to report as currently deployed")
+		fakeFlags.BoolVar(&actuate, "actuate", false, "converge the live state to published delivery configs")
+		fakeFlags.DurationVar(&actuationDelay, "actuation-delay", actuationDelay, "how long resources are actuating before they converge")
+		fakeFlags.Parse(args[1:])
+
+		fakeOpts := []mdfake.Option{}
+		if actuate {
+			fakeOpts = append(fakeOpts, mdfake.WithActuation(actuationDelay))
 		}
-		yamlIn := bytes.NewReader([]byte(os.ExpandEnv(string(yamlFile))))
-		dec := yaml.NewDecoder(yamlIn)
-		dec.KnownFields(true)
-		err = dec.Decode(&cfg)
-		if err != nil {
-			log.Fatalf("Failed to parse %s as YAML: %s", configFile, err)
+
+		opts := mdcli.NewCommandOptions()
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		opts.Context = ctx
+		if err := mdcli.FakeServer(opts, listen, liveFile, fakeOpts...); err != nil {
+			log.Fatalf("ERROR: %s", err)
 		}
-	} else if err != nil && !os.IsNotExist(err) {
-		log.Fatalf("Unable to stat %s: %s", configFile, err)
+		return
 	}
 
-	httpClient, err := gateclient.InitializeHTTPClient(cfg.Auth)
+	profilesFile := spinconfig.DefaultProfilesPath()
+	profiles, err := spinconfig.LoadProfiles(profilesFile)
 	if err != nil {
-		log.Fatalf("Failed to create client: %s", err)
+		log.Fatalf("Failed to load %s: %s", profilesFile, err)
 	}
-
-	ctx, err := gateclient.ContextWithAuth(context.Background(), cfg.Auth)
+	profileName, profileConfig, err := profiles.Lookup(profileName)
 	if err != nil {
-		log.Fatalf("Failed to extract valid login credentials from %s: %s", configFile, err)
+		log.Fatalf("Failed to load profile: %s", err)
+	}
+
+	// flags set on the command line override the profile settings
+	globalFlags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "dir":
+			profileConfig.ConfigDir = configDir
+		case "file":
+			profileConfig.ConfigFile = configFile
+		case "baseurl":
+			profileConfig.BaseURL = baseURL
+		case "ca-file":
+			profileConfig.CAFile = caFile
+		case "cert-file":
+			profileConfig.CertFile = certFile
+		case "key-file":
+			profileConfig.KeyFile = keyFile
+		case "insecure":
+			profileConfig.Insecure = insecure
+		case "proxy":
+			profileConfig.Proxy = proxy
+		case "timeout":
+			profileConfig.Timeout = timeout
+		}
+	})
+
+	if profileConfig.Insecure {
+		fmt.Fprintf(os.Stderr, "WARNING: TLS certificate verification is disabled, connections to Spinnaker are NOT secure\n")
 	}
 
 	output := func(msg string) {
 		fmt.Println(msg)
 	}
 
-	updatedConfig, err := gateclient.Authenticate(output, httpClient, cfg.Gate.Endpoint, cfg.Auth)
+	profile, err := profileConfig.Profile(profileName, output)
 	if err != nil {
-		log.Fatalf("Failed to authenticate with Spinnaker: %s", err)
+		log.Fatalf("Failed to create client: %s", err)
 	}
 
-	if updatedConfig {
-		// config updated with credential information, so write it back out
-		fd, err := os.Create(configFile)
-		if err != nil {
-			log.Fatalf("Failed to open %q: %s", configFile, err)
-		}
-		content, err := yaml.Marshal(cfg)
-		if err != nil {
-			log.Fatalf("Failed to write updated config file %q: %s", configFile, err)
-		}
-		_, err = fd.Write(content)
-		if err != nil {
-			log.Fatalf("Failed to write to configFile %q: %s", configFile, err)
-		}
-		err = fd.Close()
-		if err != nil {
-			log.Fatalf("'Failed to close configFile %q after writing: %s", configFile, err)
-		}
-	}
+	opts := mdcli.NewCommandOptionsFromProfile(profile)
+	opts.ClientOpts = append(opts.ClientOpts, mdlib.WithRetryPolicy(retryPolicy))
 
-	opts.HTTPClient = func(req *http.Request) (*http.Response, error) {
-		gateclient.AddAuthHeaders(ctx, req)
-		return httpClient.Do(req)
+	opts.ClientOpts = append(opts.ClientOpts, mdlib.WithMiddleware(
+		mdlib.UserAgentMiddleware("spinmd"),
+		mdlib.CorrelationIDMiddleware(nil),
+	))
+	if verbose {
+		opts.ClientOpts = append(opts.ClientOpts, mdlib.WithMiddleware(mdlib.DebugMiddleware(opts.Logger)))
 	}
-
-	verbose := false
-	globalFlags := flag.NewFlagSet("", flag.ContinueOnError)
-
-	globalFlags.StringVar(&opts.ConfigDir, "dir", mdlib.DefaultDeliveryConfigDirName, "directory for delivery config file")
-	globalFlags.StringVar(&opts.ConfigFile, "file", mdlib.DefaultDeliveryConfigFileName, "delivery config file name")
-	globalFlags.StringVar(&opts.BaseURL, "baseurl", cfg.Gate.Endpoint, "base URL to reach spinnaker api")
-	globalFlags.BoolVar(&verbose, "v", false, "verbose logging for rest api requests")
-	globalFlags.Parse(os.Args[1:])
-	args := globalFlags.Args()
-
-	if len(args) < 1 {
-		fmt.Printf("Usage: %s [flags] export|publish|diff|pause|resume|delete|validate|fmt\n", filepath.Base(os.Args[0]))
-		fmt.Printf("Flags:\n")
-		globalFlags.PrintDefaults()
-		return
+	if publishedDir == "" {
+		publishedDir = filepath.Join(opts.ConfigDir, ".spinnaker")
 	}
-
-	if verbose {
-		doer := opts.HTTPClient
-		opts.HTTPClient = func(req *http.Request) (resp *http.Response, err error) {
-			out, _ := httputil.DumpRequest(req, true)
-			log.Printf(string(out))
-			defer func() {
-				out, _ := httputil.DumpResponse(resp, true)
-				if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
-					out, _ = ioutil.ReadAll(resp.Body)
-					var data interface{}
-					json.Unmarshal(out, &data)
-					buf := bytes.NewBuffer(out)
-					resp.Body = ioutil.NopCloser(buf)
-					out, _ = json.MarshalIndent(data, "", "  ")
-				}
-				log.Printf(string(out))
-			}()
-			return doer(req)
+	opts.ProcessorOpts = append(opts.ProcessorOpts, mdlib.WithPublishedDirectory(publishedDir))
+	var resourcesCache *mdlib.ApplicationResourcesCache
+	if cacheDir != "" {
+		opts.ClientOpts = append(opts.ClientOpts, mdlib.WithResponseCache(mdlib.NewDiskResponseCache(filepath.Join(cacheDir, "responses"))))
+		// the resources cache is opt-in since export would offer stale resources
+		if cacheTTL > 0 {
+			resourcesCache = mdlib.NewApplicationResourcesCache(filepath.Join(cacheDir, "resources"), cacheTTL)
 		}
 	}
 
+	// cancel any in-flight requests on ctrl-c
+	cmdCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts.Context = cmdCtx
+
 	exitCode := 0
 	switch args[0] {
 	case "export":
 		var appName string
 		exportAll := false
 		envName := ""
+		refresh := false
+		concurrency := mdcli.DefaultExportConcurrency
+		ownership := &mdlib.OwnershipPolicy{}
 
 		exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
 		exportFlags.StringVar(&appName, "app", "", "spinnaker application name")
 		exportFlags.BoolVar(&exportAll, "all", false, "export all options, skip prompt")
 		exportFlags.StringVar(&envName, "env", "", "assign exported resources to given environment, skip prompt")
+		exportFlags.BoolVar(&refresh, "refresh", false, "ignore cached application resources")
+		exportFlags.IntVar(&concurrency, "concurrency", concurrency, "number of resources to export at a time")
+		exportFlags.Func("own-prefix", "also export load balancers and security groups with names starting with the prefix, can be repeated", func(prefix string) error {
+			ownership.NamePrefixes = append(ownership.NamePrefixes, prefix)
+			return nil
+		})
+		exportFlags.Func("own-pattern", "also export load balancers and security groups with names matching the regular expression, can be repeated", func(pattern string) error {
+			rx, err := regexp.Compile(pattern)
+			if err != nil {
+				return err
+			}
+			ownership.Patterns = append(ownership.Patterns, rx)
+			return nil
+		})
+		exportFlags.Func("own-app", "also export load balancers and security groups spinnaker associates with the application, can be repeated", func(app string) error {
+			ownership.Applications = append(ownership.Applications, app)
+			return nil
+		})
+		exportFlags.Func("own-allow", "also export the load balancer or security group with the name, can be repeated", func(name string) error {
+			ownership.Allow = append(ownership.Allow, name)
+			return nil
+		})
+		exportFlags.Func("own-deny", "never export the load balancer or security group with the name, can be repeated", func(name string) error {
+			ownership.Deny = append(ownership.Deny, name)
+			return nil
+		})
 		exportFlags.Parse(args[1:])
 
 		if exportFlags.NArg() > 0 || appName == "" {
@@ -145,13 +207,36 @@ func main() {
 			exportFlags.Usage()
 			return
 		}
-		exitCode, err = mdcli.Export(opts, appName, mdcli.ExportAll(exportAll), mdcli.AssumeEnvName(envName))
+		exitCode, err = mdcli.Export(opts, appName,
+			mdcli.ExportAll(exportAll),
+			mdcli.AssumeEnvName(envName),
+			mdcli.CacheResources(resourcesCache),
+			mdcli.RefreshResources(refresh),
+			mdcli.ExportConcurrency(concurrency),
+			mdcli.Ownership(mergeOwnership(mdlib.DefaultOwnershipPolicy(appName), ownership)),
+		)
 	case "publish":
-		var force bool
+		var force, wait bool
+		waitOpts := mdlib.DefaultConvergenceOptions
 		publishFlags := flag.NewFlagSet("publish", flag.ExitOnError)
 		publishFlags.BoolVar(&force, "force", false, "allow overwriting existing resources when publishing the config for the first time")
+		publishFlags.BoolVar(&wait, "wait", false, "wait for all resources to converge after publishing")
+		publishFlags.DurationVar(&waitOpts.Timeout, "wait-timeout", waitOpts.Timeout, "how long to wait for resources to converge")
+		publishFlags.DurationVar(&waitOpts.PollInterval, "wait-interval", waitOpts.PollInterval, "how often to check resource status while waiting")
 		publishFlags.Parse(args[1:])
-		exitCode, err = mdcli.Publish(opts, force)
+		if wait {
+			exitCode, err = mdcli.PublishAndWait(opts, force, waitOpts)
+		} else {
+			exitCode, err = mdcli.Publish(opts, force)
+		}
+	case "pull":
+		pullFlags := flag.NewFlagSet("pull", flag.ExitOnError)
+		pullFlags.Parse(args[1:])
+		if pullFlags.NArg() > 0 {
+			fmt.Printf("Usage: pull\n")
+			return
+		}
+		exitCode, err = mdcli.Pull(opts)
 	case "validate":
 		exitCode, err = mdcli.Validate(opts)
 	case "plan":
@@ -174,39 +259,184 @@ func main() {
 			Quiet: quiet,
 		})
 	case "pause":
-		var appName string
+		var appName, resource, envName string
 		pauseFlags := flag.NewFlagSet("pause", flag.ExitOnError)
 		pauseFlags.StringVar(&appName, "app", "", "spinnaker application name")
+		pauseFlags.StringVar(&resource, "resource", "", "id or name of a resource from the delivery config")
+		pauseFlags.StringVar(&envName, "env", "", "environment name from the delivery config")
 		pauseFlags.Parse(args[1:])
 
-		if pauseFlags.NArg() > 0 || appName == "" {
-			fmt.Printf("Usage: pause -app <name>\n")
+		set := 0
+		for _, v := range []string{appName, resource, envName} {
+			if v != "" {
+				set++
+			}
+		}
+		if pauseFlags.NArg() > 0 || set != 1 {
+			fmt.Printf("Usage: pause -app <name> | -resource <id> | -env <name>\n")
 			fmt.Printf("Flags:\n")
 			pauseFlags.Usage()
 			return
 		}
 
-		err = mdcli.Pause(opts, appName)
+		switch {
+		case resource != "":
+			err = mdcli.PauseResource(opts, resource)
+		case envName != "":
+			err = mdcli.PauseEnvironment(opts, envName)
+		default:
+			err = mdcli.Pause(opts, appName)
+		}
 	case "resume":
-		var appName string
+		var appName, resource, envName string
 		resumeFlags := flag.NewFlagSet("resume", flag.ExitOnError)
 		resumeFlags.StringVar(&appName, "app", "", "spinnaker application name")
+		resumeFlags.StringVar(&resource, "resource", "", "id or name of a resource from the delivery config")
+		resumeFlags.StringVar(&envName, "env", "", "environment name from the delivery config")
 		resumeFlags.Parse(args[1:])
 
-		if resumeFlags.NArg() > 0 || appName == "" {
-			fmt.Printf("Usage: resume -app <name>\n")
+		set := 0
+		for _, v := range []string{appName, resource, envName} {
+			if v != "" {
+				set++
+			}
+		}
+		if resumeFlags.NArg() > 0 || set != 1 {
+			fmt.Printf("Usage: resume -app <name> | -resource <id> | -env <name>\n")
 			fmt.Printf("Flags:\n")
 			resumeFlags.Usage()
 			return
 		}
 
-		err = mdcli.Resume(opts, appName)
+		switch {
+		case resource != "":
+			err = mdcli.ResumeResource(opts, resource)
+		case envName != "":
+			err = mdcli.ResumeEnvironment(opts, envName)
+		default:
+			err = mdcli.Resume(opts, appName)
+		}
+	case "status":
+		var jsonOutput bool
+		statusFlags := flag.NewFlagSet("status", flag.ExitOnError)
+		statusFlags.BoolVar(&jsonOutput, "json", false, "print the application summary as JSON")
+		statusFlags.Parse(args[1:])
+
+		if statusFlags.NArg() > 0 {
+			fmt.Printf("Usage: status\n")
+			fmt.Printf("Flags:\n")
+			statusFlags.Usage()
+			return
+		}
+		err = mdcli.Status(opts, mdcli.StatusOptions{
+			JSON: jsonOutput,
+		})
+	case "pin", "veto", "mark-bad", "mark-good":
+		target := mdlib.EnvironmentArtifactVersion{}
+		targetFlags := flag.NewFlagSet(args[0], flag.ExitOnError)
+		targetFlags.StringVar(&target.TargetEnvironment, "env", "", "environment name from the delivery config")
+		targetFlags.StringVar(&target.Reference, "artifact", "", "artifact reference from the delivery config, defaults to the only artifact")
+		targetFlags.StringVar(&target.Version, "version", "", "artifact version")
+		targetFlags.StringVar(&target.Comment, "comment", "", "reason for the change")
+		targetFlags.Parse(args[1:])
+
+		if targetFlags.NArg() > 0 || target.TargetEnvironment == "" || target.Version == "" {
+			fmt.Printf("Usage: %s -env <name> -version <version> [-artifact <reference>]\n", args[0])
+			fmt.Printf("Flags:\n")
+			targetFlags.Usage()
+			return
+		}
+
+		switch args[0] {
+		case "pin":
+			err = mdcli.Pin(opts, target)
+		case "veto":
+			err = mdcli.Veto(opts, target)
+		case "mark-bad":
+			err = mdcli.MarkBad(opts, target)
+		case "mark-good":
+			err = mdcli.MarkGood(opts, target)
+		}
+	case "history":
+		var resource, since string
+		var jsonOutput bool
+		historyOpts := mdcli.HistoryOptions{}
+		historyFlags := flag.NewFlagSet("history", flag.ExitOnError)
+		historyFlags.StringVar(&resource, "resource", "", "id or name of a resource from the delivery config")
+		historyFlags.StringVar(&since, "since", "", "only show events since a duration ago, like 24h, or an RFC3339 timestamp")
+		historyFlags.IntVar(&historyOpts.Limit, "limit", 0, "maximum number of events to request")
+		historyFlags.BoolVar(&jsonOutput, "json", false, "print the events as JSON")
+		historyFlags.Parse(args[1:])
+
+		if historyFlags.NArg() > 0 || resource == "" {
+			fmt.Printf("Usage: history -resource <id>\n")
+			fmt.Printf("Flags:\n")
+			historyFlags.Usage()
+			return
+		}
+		if since != "" {
+			historyOpts.Since, err = mdcli.ParseSince(since, time.Now())
+			if err != nil {
+				log.Fatalf("Invalid -since: %s", err)
+			}
+		}
+		historyOpts.JSON = jsonOutput
+		err = mdcli.History(opts, resource, historyOpts)
+	case "approve":
+		approveOpts := mdcli.ApproveOptions{}
+		approveFlags := flag.NewFlagSet("approve", flag.ExitOnError)
+		approveFlags.StringVar(&approveOpts.Environment, "env", "", "environment name from the delivery config, skips the prompt")
+		approveFlags.StringVar(&approveOpts.Version, "version", "", "artifact version, skips the prompt")
+		approveFlags.StringVar(&approveOpts.Reference, "artifact", "", "artifact reference from the delivery config, skips the prompt")
+		approveFlags.StringVar(&approveOpts.Type, "type", mdcli.DefaultConstraintType, "constraint type to judge")
+		approveFlags.BoolVar(&approveOpts.Reject, "reject", false, "reject the constraint instead of approving it")
+		approveFlags.StringVar(&approveOpts.Comment, "comment", "", "reason for the judgement")
+		approveFlags.Parse(args[1:])
+
+		if approveFlags.NArg() > 0 {
+			fmt.Printf("Usage: approve [-env <name>] [-version <version>] [-artifact <reference>]\n")
+			fmt.Printf("Flags:\n")
+			approveFlags.Usage()
+			return
+		}
+		exitCode, err = mdcli.Approve(opts, approveOpts)
+	case "verifications":
+		verificationsOpts := mdcli.VerificationsOptions{}
+		verificationsFlags := flag.NewFlagSet("verifications", flag.ExitOnError)
+		verificationsFlags.StringVar(&verificationsOpts.Environment, "env", "", "only report verifications for this environment")
+		verificationsFlags.StringVar(&verificationsOpts.Version, "version", "", "only report verifications for this artifact version")
+		verificationsFlags.StringVar(&verificationsOpts.Reference, "artifact", "", "only report verifications for this artifact reference")
+		verificationsFlags.BoolVar(&verificationsOpts.JSON, "json", false, "print verifications as JSON")
+		verificationsFlags.Parse(args[1:])
+
+		if verificationsFlags.NArg() > 0 {
+			fmt.Printf("Usage: verifications [-env <name>] [-version <version>]\n")
+			fmt.Printf("Flags:\n")
+			verificationsFlags.Usage()
+			return
+		}
+		exitCode, err = mdcli.Verifications(opts, verificationsOpts)
+	case "unpin":
+		var envName, reference string
+		unpinFlags := flag.NewFlagSet("unpin", flag.ExitOnError)
+		unpinFlags.StringVar(&envName, "env", "", "environment name from the delivery config")
+		unpinFlags.StringVar(&reference, "artifact", "", "artifact reference from the delivery config, defaults to the only artifact")
+		unpinFlags.Parse(args[1:])
+
+		if unpinFlags.NArg() > 0 || envName == "" {
+			fmt.Printf("Usage: unpin -env <name> [-artifact <reference>]\n")
+			fmt.Printf("Flags:\n")
+			unpinFlags.Usage()
+			return
+		}
+
+		err = mdcli.Unpin(opts, envName, reference)
 	case "fmt":
 		err = mdcli.Format(
 			opts,
 		)
 	default:
-		log.Fatalf(`Unexpected command %q, expected one of export|publish|diff|pause|resume|delete|validate|fmt|plan`, args[0])
+		log.Fatalf(`Unexpected command %q, expected one of export|publish|pull|diff|pause|resume|delete|validate|fmt|plan|status|history|approve|verifications|pin|unpin|veto|mark-bad|mark-good|fake-server`, args[0])
 	}
 
 	if err != nil {
@@ -214,3 +444,13 @@ func main() {
 	}
 	os.Exit(exitCode)
 }
+
+// mergeOwnership returns the policy with the rules from extra added.
+func mergeOwnership(policy, extra *mdlib.OwnershipPolicy) *mdlib.OwnershipPolicy {
+	policy.NamePrefixes = append(policy.NamePrefixes, extra.NamePrefixes...)
+	policy.Patterns = append(policy.Patterns, extra.Patterns...)
+	policy.Allow = append(policy.Allow, extra.Allow...)
+	policy.Deny = append(policy.Deny, extra.Deny...)
+	policy.Applications = append(policy.Applications, extra.Applications...)
+	return policy
+}
@@ -0,0 +1,144 @@
+package mdlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// ConstraintStatus is the state of an environment constraint for an artifact version
+type ConstraintStatus string
+
+const (
+	// ConstraintPending is the status of a constraint waiting to be evaluated or judged
+	ConstraintPending ConstraintStatus = "PENDING"
+	// ConstraintOverridePass is the status of a constraint that was manually approved
+	ConstraintOverridePass ConstraintStatus = "OVERRIDE_PASS"
+	// ConstraintOverrideFail is the status of a constraint that was manually rejected
+	ConstraintOverrideFail ConstraintStatus = "OVERRIDE_FAIL"
+)
+
+// ConstraintState is the state of a constraint, like `manual-judgement`, for an artifact
+// version in an environment.
+type ConstraintState struct {
+	DeliveryConfigName string           `json:"deliveryConfigName" yaml:"deliveryConfigName"`
+	EnvironmentName    string           `json:"environmentName" yaml:"environmentName"`
+	ArtifactReference  string           `json:"artifactReference" yaml:"artifactReference"`
+	ArtifactVersion    string           `json:"artifactVersion" yaml:"artifactVersion"`
+	Type               string           `json:"type" yaml:"type"`
+	Status             ConstraintStatus `json:"status" yaml:"status"`
+	CreatedAt          time.Time        `json:"createdAt" yaml:"createdAt"`
+	JudgedBy           string           `json:"judgedBy,omitempty" yaml:"judgedBy,omitempty"`
+	JudgedAt           *time.Time       `json:"judgedAt,omitempty" yaml:"judgedAt,omitempty"`
+	Comment            string           `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+// String returns the constraint as "type for reference version in environment"
+func (s ConstraintState) String() string {
+	return fmt.Sprintf("%s for %s %s in %s", s.Type, s.ArtifactReference, s.ArtifactVersion, s.EnvironmentName)
+}
+
+// ConstraintStatusUpdate is used to manually approve or reject a constraint.
+type ConstraintStatusUpdate struct {
+	Type              string           `json:"type" yaml:"type"`
+	ArtifactReference string           `json:"artifactReference,omitempty" yaml:"artifactReference,omitempty"`
+	ArtifactVersion   string           `json:"artifactVersion" yaml:"artifactVersion"`
+	Status            ConstraintStatus `json:"status" yaml:"status"`
+	Comment           string           `json:"comment,omitempty" yaml:"comment,omitempty"`
+}
+
+// GetConstraintStates returns the recent constraint states for the environment envName,
+// for all artifact versions.
+func GetConstraintStates(cli *Client, appName, envName string) ([]ConstraintState, error) {
+	return GetConstraintStatesContext(context.Background(), cli, appName, envName)
+}
+
+// GetConstraintStatesContext is like GetConstraintStates but the request is bound to ctx.
+func GetConstraintStatesContext(ctx context.Context, cli *Client, appName, envName string) ([]ConstraintState, error) {
+	states := []ConstraintState{}
+	u := fmt.Sprintf("/managed/application/%s/environment/%s/constraints", url.PathEscape(appName), url.PathEscape(envName))
+	err := commonParsedGet(ctx, cli, u, &states)
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// PendingConstraints returns the pending constraint states for the environments envNames.
+func PendingConstraints(cli *Client, appName string, envNames []string) ([]ConstraintState, error) {
+	return PendingConstraintsContext(context.Background(), cli, appName, envNames)
+}
+
+// PendingConstraintsContext is like PendingConstraints but the requests are bound to ctx.
+func PendingConstraintsContext(ctx context.Context, cli *Client, appName string, envNames []string) ([]ConstraintState, error) {
+	pending := []ConstraintState{}
+	for _, envName := range envNames {
+		states, err := GetConstraintStatesContext(ctx, cli, appName, envName)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get constraints for environment %s: %w", envName, err)
+		}
+		for _, state := range states {
+			if state.Status == ConstraintPending {
+				pending = append(pending, state)
+			}
+		}
+	}
+	return pending, nil
+}
+
+// UpdateConstraintStatus will manually set the status of a constraint for an artifact
+// version in the environment envName.
+func UpdateConstraintStatus(cli *Client, appName, envName string, update ConstraintStatusUpdate) error {
+	return UpdateConstraintStatusContext(context.Background(), cli, appName, envName, update)
+}
+
+// UpdateConstraintStatusContext is like UpdateConstraintStatus but the request is bound to ctx.
+func UpdateConstraintStatusContext(ctx context.Context, cli *Client, appName, envName string, update ConstraintStatusUpdate) error {
+	content, err := json.Marshal(update)
+	if err != nil {
+		return xerrors.Errorf("failed to encode constraint status: %w", err)
+	}
+	u := fmt.Sprintf("/managed/application/%s/environment/%s/constraint", url.PathEscape(appName), url.PathEscape(envName))
+	_, err = commonRequest(ctx, cli, "POST", u, requestBody{
+		Content:     bytes.NewReader(content),
+		ContentType: "application/json",
+	})
+	return err
+}
+
+// ApproveConstraint will manually approve the constraint so the artifact version can be
+// promoted to the environment.
+func ApproveConstraint(cli *Client, appName string, state ConstraintState, comment string) error {
+	return ApproveConstraintContext(context.Background(), cli, appName, state, comment)
+}
+
+// ApproveConstraintContext is like ApproveConstraint but the request is bound to ctx.
+func ApproveConstraintContext(ctx context.Context, cli *Client, appName string, state ConstraintState, comment string) error {
+	return judgeConstraint(ctx, cli, appName, state, ConstraintOverridePass, comment)
+}
+
+// RejectConstraint will manually reject the constraint so the artifact version will not
+// be promoted to the environment.
+func RejectConstraint(cli *Client, appName string, state ConstraintState, comment string) error {
+	return RejectConstraintContext(context.Background(), cli, appName, state, comment)
+}
+
+// RejectConstraintContext is like RejectConstraint but the request is bound to ctx.
+func RejectConstraintContext(ctx context.Context, cli *Client, appName string, state ConstraintState, comment string) error {
+	return judgeConstraint(ctx, cli, appName, state, ConstraintOverrideFail, comment)
+}
+
+func judgeConstraint(ctx context.Context, cli *Client, appName string, state ConstraintState, status ConstraintStatus, comment string) error {
+	return UpdateConstraintStatusContext(ctx, cli, appName, state.EnvironmentName, ConstraintStatusUpdate{
+		Type:              state.Type,
+		ArtifactReference: state.ArtifactReference,
+		ArtifactVersion:   state.ArtifactVersion,
+		Status:            status,
+		Comment:           comment,
+	})
+}
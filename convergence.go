@@ -0,0 +1,198 @@
+package mdlib
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// ConvergenceOptions controls how WaitForConvergence polls the Spinnaker API.
+type ConvergenceOptions struct {
+	// ResourceIDs are the resources to wait for, all the resources of the
+	// application are used when empty.
+	ResourceIDs []string
+	// PollInterval is the delay between requests for the application summary,
+	// DefaultConvergenceOptions.PollInterval is used when not set.
+	PollInterval time.Duration
+	// Timeout is how long to wait for convergence, 0 waits until ctx is done.
+	Timeout time.Duration
+	// Changed are the resources expected to change, like the resources with a
+	// diff before publishing.  The status of a changed resource may be from
+	// before the publish until Spinnaker checks it again, so a converged status
+	// is only accepted after the resource was seen in another status.
+	Changed []string
+	// Since is when the delivery config was published, a changed resource with an
+	// event since then was checked by Spinnaker and its status is current.  The
+	// time the wait started is used when not set.
+	Since time.Time
+	// Progress is called with the resources that are still converging after
+	// each poll, it can be nil.
+	Progress func(pending []ResourceSummary)
+}
+
+// DefaultConvergenceOptions are reasonable ConvergenceOptions for waiting on
+// a newly published delivery config.
+var DefaultConvergenceOptions = ConvergenceOptions{
+	PollInterval: 10 * time.Second,
+	Timeout:      30 * time.Minute,
+}
+
+// ConvergedStatuses are the resource statuses that indicate the resource matches
+// the delivery config.  Any status that is neither converged nor failed, including
+// statuses unknown to this library, is treated as still converging.
+var ConvergedStatuses = []ResourceStatus{"HAPPY"}
+
+// FailedStatuses are the resource statuses that indicate the resource will not
+// converge without intervention.
+var FailedStatuses = []ResourceStatus{"ERROR", "UNHAPPY", "PAUSED", "CURRENTLY_UNRESOLVABLE", "MISSING_DEPENDENCY"}
+
+// ErrorNotConverged is returned from WaitForConvergence when resources failed, or
+// did not converge before the timeout.
+type ErrorNotConverged struct {
+	Failed  []ResourceSummary
+	Pending []ResourceSummary
+	// Err is the context error when the wait timed out or was canceled.
+	Err error
+}
+
+// Error returns the error message
+func (e ErrorNotConverged) Error() string {
+	parts := []string{}
+	for _, resource := range append(append([]ResourceSummary{}, e.Failed...), e.Pending...) {
+		parts = append(parts, string(resource.Status)+" "+resource.ID)
+	}
+	msg := "resources did not converge: " + strings.Join(parts, ", ")
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the context error, if any, so the error can be matched against
+// context.DeadlineExceeded via errors.Is
+func (e ErrorNotConverged) Unwrap() error {
+	return e.Err
+}
+
+// WaitForConvergence polls the application summary until every resource has
+// converged.  An ErrorNotConverged is returned if any resource fails, or if the
+// resources are still converging when the timeout expires.  Resources missing
+// from the summary are treated as still converging since Spinnaker may not have
+// processed a newly published delivery config yet, as are the opts.Changed
+// resources until they have been seen in a status other than converged or have
+// an event since opts.Since.
+func WaitForConvergence(ctx context.Context, cli *Client, appName string, opts ConvergenceOptions) (*ApplicationSummary, error) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultConvergenceOptions.PollInterval
+	}
+	if opts.Since.IsZero() {
+		opts.Since = time.Now()
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	// stale are the changed resources that have not been seen converging yet
+	stale := map[string]bool{}
+	for _, id := range opts.Changed {
+		stale[id] = true
+	}
+
+	var pending []ResourceSummary
+	for {
+		summary, err := GetApplicationSummaryContext(ctx, cli, appName)
+		if err == nil {
+			err = checkStale(ctx, cli, summary, stale, opts.Since)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ErrorNotConverged{Pending: pending, Err: ctx.Err()}
+			}
+			return nil, xerrors.Errorf("failed to get convergence state for %s: %w", appName, err)
+		}
+
+		var failed []ResourceSummary
+		pending, failed = convergenceState(summary, opts.ResourceIDs, stale)
+		if len(failed) > 0 {
+			return summary, ErrorNotConverged{Failed: failed, Pending: pending}
+		}
+		if len(pending) == 0 {
+			return summary, nil
+		}
+		if opts.Progress != nil {
+			opts.Progress(pending)
+		}
+
+		timer := time.NewTimer(opts.PollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return summary, ErrorNotConverged{Pending: pending, Err: ctx.Err()}
+		case <-timer.C:
+		}
+	}
+}
+
+// convergenceState returns the resources that are still converging and the
+// resources that failed, sorted by id.  A converged resource in stale is still
+// converging, stale resources are removed once seen in any other status.
+func convergenceState(summary *ApplicationSummary, resourceIDs []string, stale map[string]bool) (pending, failed []ResourceSummary) {
+	ids := append([]string{}, resourceIDs...)
+	if len(ids) == 0 {
+		for _, resource := range summary.Resources {
+			ids = append(ids, resource.ID)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		resource, ok := summary.Resource(id)
+		if !ok {
+			pending = append(pending, ResourceSummary{ID: id, Status: "UNKNOWN"})
+			continue
+		}
+		converged := hasStatus(ConvergedStatuses, resource.Status)
+		if !converged {
+			delete(stale, id)
+		}
+		switch {
+		case hasStatus(FailedStatuses, resource.Status):
+			failed = append(failed, resource)
+		case !converged || stale[id]:
+			pending = append(pending, resource)
+		}
+	}
+	return pending, failed
+}
+
+// checkStale removes the stale resources with a converged status and an event since
+// the publish, they were checked by Spinnaker and converged between polls.
+func checkStale(ctx context.Context, cli *Client, summary *ApplicationSummary, stale map[string]bool, since time.Time) error {
+	for id := range stale {
+		resource, ok := summary.Resource(id)
+		if !ok || !hasStatus(ConvergedStatuses, resource.Status) {
+			continue
+		}
+		events, err := GetResourceEventsContext(ctx, cli, id, 1)
+		if err != nil {
+			return xerrors.Errorf("failed to get events for %s: %w", id, err)
+		}
+		if len(events) > 0 && !events[0].Timestamp.Before(since) {
+			delete(stale, id)
+		}
+	}
+	return nil
+}
+
+func hasStatus(statuses []ResourceStatus, status ResourceStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
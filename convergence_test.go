@@ -0,0 +1,140 @@
+package mdlib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForConvergence(t *testing.T) {
+	polls := 0
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/managed/application/myapp", r.URL.Path)
+				polls++
+				status := ResourceStatus("ACTUATING")
+				if polls >= 3 {
+					status = "HAPPY"
+				}
+				json.NewEncoder(w).Encode(ApplicationSummary{
+					Resources: []ResourceSummary{
+						{ID: "ec2:cluster:test:myapp", Status: status},
+						{ID: "ec2:cluster:test:other", Status: "DIFF"},
+					},
+				})
+			},
+		),
+	)
+	defer ts.Close()
+
+	cli := NewClient(WithBaseURL(ts.URL))
+	progress := [][]ResourceSummary{}
+	_, err := WaitForConvergence(context.Background(), cli, "myapp", ConvergenceOptions{
+		ResourceIDs:  []string{"ec2:cluster:test:myapp"},
+		PollInterval: time.Millisecond,
+		Progress: func(pending []ResourceSummary) {
+			progress = append(progress, pending)
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, polls)
+	require.Len(t, progress, 2)
+
+	// other never converges
+	_, err = WaitForConvergence(context.Background(), cli, "myapp", ConvergenceOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      20 * time.Millisecond,
+	})
+	var notConverged ErrorNotConverged
+	require.True(t, errors.As(err, &notConverged))
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Equal(t, []ResourceSummary{{ID: "ec2:cluster:test:other", Status: "DIFF"}}, notConverged.Pending)
+}
+
+func TestWaitForConvergenceStatuses(t *testing.T) {
+	// wait starts a server returning statuses for each poll, repeating the last
+	// one, and events for the resource.  It returns the number of polls once the
+	// server is closed so no handler is still running.
+	wait := func(statuses []ResourceStatus, events []ResourceEvent, opts ConvergenceOptions) (int, error) {
+		var mu sync.Mutex
+		polls := 0
+		ts := httptest.NewServer(
+			http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/managed/resources/events/ec2:cluster:test:myapp" {
+						json.NewEncoder(w).Encode(events)
+						return
+					}
+					mu.Lock()
+					status := statuses[len(statuses)-1]
+					if polls < len(statuses) {
+						status = statuses[polls]
+					}
+					polls++
+					mu.Unlock()
+					json.NewEncoder(w).Encode(ApplicationSummary{
+						Resources: []ResourceSummary{
+							{ID: "ec2:cluster:test:myapp", Status: status},
+						},
+					})
+				},
+			),
+		)
+		opts.PollInterval = time.Millisecond
+		_, err := WaitForConvergence(context.Background(), NewClient(WithBaseURL(ts.URL)), "myapp", opts)
+		ts.Close()
+		mu.Lock()
+		defer mu.Unlock()
+		return polls, err
+	}
+
+	// statuses unknown to the library are not converged
+	_, err := wait([]ResourceStatus{"SOMETHING_NEW"}, nil, ConvergenceOptions{
+		Timeout: 20 * time.Millisecond,
+	})
+	var notConverged ErrorNotConverged
+	require.True(t, errors.As(err, &notConverged))
+	require.Equal(t, []ResourceSummary{{ID: "ec2:cluster:test:myapp", Status: "SOMETHING_NEW"}}, notConverged.Pending)
+
+	// a changed resource is HAPPY from before the publish until spinnaker checks it
+	published := time.Now()
+	polls, err := wait(
+		[]ResourceStatus{"HAPPY", "HAPPY", "DIFF", "ACTUATING", "HAPPY"},
+		[]ResourceEvent{{Type: "ResourceDeltaResolved", Timestamp: published.Add(-time.Hour)}},
+		ConvergenceOptions{
+			Changed: []string{"ec2:cluster:test:myapp"},
+			Since:   published,
+			Timeout: 5 * time.Second,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 5, polls)
+
+	// or it converged between polls
+	polls, err = wait(
+		[]ResourceStatus{"HAPPY"},
+		[]ResourceEvent{{Type: "ResourceDeltaResolved", Timestamp: published.Add(time.Second)}},
+		ConvergenceOptions{
+			Changed: []string{"ec2:cluster:test:myapp"},
+			Since:   published,
+			Timeout: 5 * time.Second,
+		},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, polls)
+
+	// unchanged resources are converged on the first poll
+	polls, err = wait([]ResourceStatus{"HAPPY"}, nil, ConvergenceOptions{
+		Timeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, polls)
+}
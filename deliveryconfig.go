@@ -2,11 +2,13 @@ package mdlib
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -92,8 +94,26 @@ func (a *DeliveryArtifact) Equal(b *DeliveryArtifact) bool {
 
 // DeliveryResource contains the necessary configuration for a managed delivery resource
 type DeliveryResource struct {
-	Kind string
-	Spec DeliveryResourceSpec
+	// APIVersion is only set for resources using the legacy format where Kind
+	// is just the resource type, like `cluster`
+	APIVersion string `json:"apiVersion,omitempty" yaml:"apiVersion,omitempty"`
+	Kind       string
+	Spec       DeliveryResourceSpec
+}
+
+// ID returns the id Spinnaker uses for the resource, like `ec2:cluster:test:myapp`
+func (r DeliveryResource) ID() string {
+	group, resourceType := "", r.Kind
+	if ix := strings.Index(r.Kind, "/"); ix >= 0 {
+		group, resourceType = r.Kind[:ix], r.Kind[ix+1:]
+		if ix := strings.LastIndex(resourceType, "@"); ix >= 0 {
+			resourceType = resourceType[:ix]
+		}
+	} else if r.APIVersion != "" {
+		// legacy format like `ec2.spinnaker.netflix.com/v1`
+		group = strings.SplitN(r.APIVersion, ".", 2)[0]
+	}
+	return fmt.Sprintf("%s:%s:%s:%s", group, resourceType, r.Account(), r.Name())
 }
 
 // Name returns the name for the type of delivery resource
@@ -106,28 +126,34 @@ func (r DeliveryResource) Account() string {
 	return r.Spec.Locations.Account
 }
 
-// CloudProvider returns the cloud provider for a resource.  Currently it
-// only return titus or aws
+// Namespace returns the namespace for the delivery resource, it is only set for
+// resources like kubernetes manifests.
+func (r DeliveryResource) Namespace() string {
+	return r.Spec.Locations.Namespace
+}
+
+// CloudProvider returns the cloud provider for a resource, like aws or titus,
+// from the registered CloudProvider for the kind.
 func (r DeliveryResource) CloudProvider() string {
 	// Kind is like ec2/cluster@v1 or titus/cluster@v1
 	// but CloudProvider needs to be "aws" for "ec2"
-	// so make that mapping here
+	if provider, ok := CloudProviderForKind(r.Kind); ok {
+		return provider.Name
+	}
 	parts := strings.SplitN(r.Kind, "/", 2)
 	if len(parts) == 0 {
 		return "unknown-cloud-provider"
 	}
-	if parts[0] == "ec2" {
-		return "aws"
-	}
 	return parts[0]
 }
 
 // Match will return true if the ExportableResource matches the
-// Kind, CloudProvider, Account and Name properties
+// Kind, CloudProvider, Account, Namespace and Name properties
 func (r *DeliveryResource) Match(e *ExportableResource) bool {
 	if e.HasKind(r.Kind) &&
 		r.CloudProvider() == e.CloudProvider &&
 		r.Account() == e.Account &&
+		r.Namespace() == e.Namespace &&
 		r.Name() == e.Name {
 		return true
 	}
@@ -155,11 +181,13 @@ type DeliveryResourceSpec struct {
 type DeliveryResourceLocations struct {
 	Account string
 	Regions []DeliveryResourceLocationRegion
+	// Namespace is used instead of Regions for kubernetes resources
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
 }
 
 // Empty will return true if the DeliveryResourceLocations has no values set
 func (l DeliveryResourceLocations) Empty() bool {
-	return l.Account == "" && len(l.Regions) == 0
+	return l.Account == "" && len(l.Regions) == 0 && l.Namespace == ""
 }
 
 // DeliveryResourceLocationRegion contains the region name
@@ -185,6 +213,7 @@ type DeliveryConfigProcessor struct {
 	appName               string
 	fileName              string
 	dirName               string
+	publishedDir          string
 	rawDeliveryConfig     *yaml.Node
 	deliveryConfig        DeliveryConfig
 	content               []byte
@@ -247,6 +276,17 @@ func WithFile(f string) ProcessorOption {
 	}
 }
 
+// WithPublishedDirectory is a ProcessorOption to set the directory where the last published
+// delivery config for each application is recorded.  It is used as the common ancestor when
+// merging changes with Pull, nothing is recorded when not set.  The directory should be
+// next to the delivery config, like `.spinnaker`, so each checkout merges with the config
+// it published rather than one published from another checkout.
+func WithPublishedDirectory(d string) ProcessorOption {
+	return func(p *DeliveryConfigProcessor) {
+		p.publishedDir = d
+	}
+}
+
 // WithAppName is a ProcessorOption to set the name of the Spinnaker application name that the delivery config corresponds to.
 // It is only necessary to set when exporting/creating a delivery config.
 func WithAppName(a string) ProcessorOption {
@@ -478,6 +518,24 @@ func configKeySort(node *yaml.Node) {
 	}
 }
 
+// Application returns the application name from the delivery config, or the name
+// set with WithAppName if the delivery config does not have one.
+func (p *DeliveryConfigProcessor) Application() string {
+	if p.deliveryConfig.Application != "" {
+		return p.deliveryConfig.Application
+	}
+	return p.appName
+}
+
+// EnvironmentNames returns the names of the environments in the delivery config.
+func (p *DeliveryConfigProcessor) EnvironmentNames() []string {
+	names := []string{}
+	for _, env := range p.deliveryConfig.Environments {
+		names = append(names, env.Name)
+	}
+	return names
+}
+
 // AllEnvironments will return a list of the names of all the environments in the delivery config as well
 // as the default/recommended environment names: testing, staging, and production.
 func (p *DeliveryConfigProcessor) AllEnvironments() []string {
@@ -663,6 +721,91 @@ func (p *DeliveryConfigProcessor) ResourceExists(search *ExportableResource) boo
 	return false
 }
 
+// EnvironmentResources returns the resources in the environment envName from the delivery
+// config.  Resources without locations inherit the locations from the environment.
+func (p *DeliveryConfigProcessor) EnvironmentResources(envName string) ([]*DeliveryResource, error) {
+	eix := p.findEnvIndex(envName)
+	if eix < 0 {
+		return nil, xerrors.Errorf("environment %q not found in delivery config", envName)
+	}
+	env := p.deliveryConfig.Environments[eix]
+	resources := []*DeliveryResource{}
+	for _, resource := range env.Resources {
+		r := *resource
+		if r.Spec.Locations.Empty() {
+			r.Spec.Locations = env.Locations
+		}
+		resources = append(resources, &r)
+	}
+	return resources, nil
+}
+
+// ResourceIDs returns the ids of all the resources in the delivery config.
+func (p *DeliveryConfigProcessor) ResourceIDs() []string {
+	ids := []string{}
+	for _, env := range p.deliveryConfig.Environments {
+		resources, _ := p.EnvironmentResources(env.Name)
+		for _, resource := range resources {
+			ids = append(ids, resource.ID())
+		}
+	}
+	return ids
+}
+
+// ResourceID returns the id of the resource in the delivery config matching search, which
+// can be either the resource id, like `ec2:cluster:test:myapp`, or the resource name.  An
+// error is returned if no resource, or more than one resource, matches.
+func (p *DeliveryConfigProcessor) ResourceID(search string) (string, error) {
+	matches := []string{}
+	for _, env := range p.deliveryConfig.Environments {
+		resources, err := p.EnvironmentResources(env.Name)
+		if err != nil {
+			return "", err
+		}
+		for _, resource := range resources {
+			id := resource.ID()
+			if id == search {
+				return id, nil
+			}
+			if resource.Name() == search {
+				matches = append(matches, id)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", xerrors.Errorf("resource %q not found in delivery config", search)
+	case 1:
+		return matches[0], nil
+	}
+	return "", xerrors.Errorf("resource name %q is ambiguous, use one of the resource ids: %s", search, strings.Join(matches, ", "))
+}
+
+// ValidateArtifactTarget returns an error if the environment envName or the artifact
+// with the given reference are not found in the delivery config.
+func (p *DeliveryConfigProcessor) ValidateArtifactTarget(envName, reference string) error {
+	if p.findEnvIndex(envName) < 0 {
+		return xerrors.Errorf("environment %q not found in delivery config, expected one of: %s", envName, strings.Join(p.EnvironmentNames(), ", "))
+	}
+	refs := []string{}
+	for _, artifact := range p.deliveryConfig.Artifacts {
+		if artifact.RefName() == reference {
+			return nil
+		}
+		refs = append(refs, artifact.RefName())
+	}
+	return xerrors.Errorf("artifact %q not found in delivery config, expected one of: %s", reference, strings.Join(refs, ", "))
+}
+
+// DefaultArtifactReference returns the reference for the only artifact in the delivery
+// config, or an empty string if the delivery config does not have exactly one artifact.
+func (p *DeliveryConfigProcessor) DefaultArtifactReference() string {
+	if len(p.deliveryConfig.Artifacts) != 1 {
+		return ""
+	}
+	return p.deliveryConfig.Artifacts[0].RefName()
+}
+
 // InsertArtifact will add an artifact to the delivery config if it is not already present.
 func (p *DeliveryConfigProcessor) InsertArtifact(artifact *DeliveryArtifact) (added bool, updatedRef string) {
 	// TODO change this to detect changes in artifacts, not simple equality.  If
@@ -710,51 +853,18 @@ func (p *DeliveryConfigProcessor) UpdateArtifactReference(content *[]byte, updat
 		return xerrors.Errorf("Failed to parse resource as yaml to updated reference: %w", err)
 	}
 	if kind, ok := data["kind"].(string); ok {
-		switch {
-		case strings.HasPrefix(kind, "titus/cluster@v1"):
-			// kind: titus/cluster@v1
-			// spec:
-			//   container:
-			//     reference: some/image
-			if spec, ok := data["spec"].(map[string]interface{}); ok {
-				if container, ok := spec["container"].(map[string]interface{}); ok {
-					container["reference"] = updatedRef
-					spec["container"] = container
-					data["spec"] = spec
-				} else {
-					return xerrors.New("resource for titus/cluster@v1 missing spec.cluster property")
-				}
-			} else {
-				return xerrors.New("resource for titus/cluster@v1 missing spec property")
-			}
-		case kind == "ec2/cluster@v1":
-			// kind: ec2/cluster@v1
-			// spec:
-			//   imageProvider:
-			//     reference: some-deb
-			if spec, ok := data["spec"].(map[string]interface{}); ok {
-				if imageProvider, ok := spec["imageProvider"].(map[string]interface{}); ok {
-					imageProvider["reference"] = updatedRef
-					spec["imageProvider"] = imageProvider
-					data["spec"] = spec
-				} else {
-					return xerrors.New("resource for ec2/cluster@v1 missing spec.imageProvider property")
-				}
-			} else {
-				return xerrors.New("resource for ec2/cluster@v1 missing spec property")
-			}
-		case kind == "ec2/cluster@v1.1":
-			// kind: ec2/cluster@v1.1
-			// spec:
-			//   artifactReference: some-deb
-			if spec, ok := data["spec"].(map[string]interface{}); ok {
-				spec["artifactReference"] = updatedRef
-			} else {
-				return xerrors.New("resource for ec2/cluster@v1 missing spec property")
-			}
-		default:
+		provider, ok := CloudProviderForKind(kind)
+		if !ok {
 			return xerrors.Errorf("cannot update artifact reference for unexpected kind: %q", kind)
 		}
+		spec, ok := data["spec"].(map[string]interface{})
+		if !ok {
+			return xerrors.Errorf("resource for %s missing spec property", kind)
+		}
+		err = provider.UpdateArtifactReference(kind, spec, updatedRef)
+		if err != nil {
+			return err
+		}
 	}
 	updated, err := yaml.Marshal(&data)
 	if err != nil {
@@ -767,6 +877,11 @@ func (p *DeliveryConfigProcessor) UpdateArtifactReference(content *[]byte, updat
 // Publish will post the delivery config to the Spinnaker API so that Spinnaker
 // will update the Managed state for the application.
 func (p *DeliveryConfigProcessor) Publish(cli *Client, force bool) error {
+	return p.PublishContext(context.Background(), cli, force)
+}
+
+// PublishContext is like Publish but the request is bound to ctx.
+func (p *DeliveryConfigProcessor) PublishContext(ctx context.Context, cli *Client, force bool) error {
 	if p.rawDeliveryConfig == nil {
 		err := p.Load()
 		if err != nil {
@@ -774,7 +889,7 @@ func (p *DeliveryConfigProcessor) Publish(cli *Client, force bool) error {
 		}
 	}
 
-	_, err := commonRequest(cli, "POST", fmt.Sprintf("/managed/delivery-configs?force=%t", force), requestBody{
+	_, err := commonRequest(ctx, cli, "POST", fmt.Sprintf("/managed/delivery-configs?force=%t", force), requestBody{
 		Content:     bytes.NewReader(p.content),
 		ContentType: "application/x-yaml",
 	})
@@ -782,9 +897,127 @@ func (p *DeliveryConfigProcessor) Publish(cli *Client, force bool) error {
 		return xerrors.Errorf("Failed to post delivery config to spinnaker: %w", err)
 	}
 
+	err = p.recordPublished(p.content)
+	if err != nil {
+		p.log.Errorf("WARNING: %s", err)
+	}
 	return nil
 }
 
+// Pull will fetch the delivery config stored in Spinnaker for the application and merge
+// any changes made to it since the last publish into the local delivery config.  When
+// changes made in Spinnaker conflict with local changes the conflicts are returned and
+// the local delivery config is not modified.  The last publish is only known when
+// WithPublishedDirectory is used, see MergeDeliveryConfig for merging without it.
+func (p *DeliveryConfigProcessor) Pull(cli *Client) ([]MergeConflict, error) {
+	return p.PullContext(context.Background(), cli)
+}
+
+// PullContext is like Pull but the request is bound to ctx.
+func (p *DeliveryConfigProcessor) PullContext(ctx context.Context, cli *Client) ([]MergeConflict, error) {
+	if p.rawDeliveryConfig == nil {
+		err := p.Load()
+		if err != nil {
+			return nil, xerrors.Errorf("Failed to load delivery config: %w", err)
+		}
+	}
+	appName := p.Application()
+	if appName == "" {
+		return nil, xerrors.New("application name is required to pull the delivery config")
+	}
+
+	content, err := commonRequest(ctx, cli, "GET", fmt.Sprintf("/managed/application/%s/config", url.PathEscape(appName)), requestBody{})
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to get delivery config from spinnaker: %w", err)
+	}
+	remote := &yaml.Node{}
+	err = yaml.Unmarshal(content, remote)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to parse delivery config from spinnaker: %w", ErrorInvalidContent{Content: content, ParseError: err})
+	}
+	normalizeRemote(nil, remote)
+
+	base, err := p.loadPublished(appName)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		p.log.Noticef("No published delivery config recorded for %s, every difference from Spinnaker will conflict", appName)
+	}
+
+	merged, conflicts := MergeDeliveryConfig(base, p.rawDeliveryConfig, remote)
+	if len(conflicts) > 0 {
+		return conflicts, nil
+	}
+
+	remoteContent, err := p.yamlMarshal(remote)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to marshal delivery config from spinnaker: %w", err)
+	}
+
+	if nodeEqual(merged, p.rawDeliveryConfig) {
+		p.log.Noticef("Already up to date")
+	} else {
+		deliveryConfig := DeliveryConfig{}
+		err = merged.Decode(&deliveryConfig)
+		if err != nil {
+			return nil, xerrors.Errorf("Failed to parse merged delivery config: %w", err)
+		}
+		p.rawDeliveryConfig = merged
+		p.deliveryConfig = deliveryConfig
+		err = p.Save()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the remote config is now the common ancestor for the next pull
+	err = p.recordPublished(remoteContent)
+	if err != nil {
+		p.log.Errorf("WARNING: %s", err)
+	}
+	return nil, nil
+}
+
+func (p *DeliveryConfigProcessor) publishedPath(appName string) string {
+	return filepath.Join(p.publishedDir, url.PathEscape(appName)+".yml")
+}
+
+// recordPublished stores content as the last published delivery config for the
+// application, if WithPublishedDirectory was used.
+func (p *DeliveryConfigProcessor) recordPublished(content []byte) error {
+	appName := p.Application()
+	if p.publishedDir == "" || appName == "" {
+		return nil
+	}
+	err := writeCacheFile(p.publishedPath(appName), content)
+	if err != nil {
+		return xerrors.Errorf("failed to record published delivery config: %w", err)
+	}
+	return nil
+}
+
+// loadPublished returns the last published delivery config for the application, or nil
+// if none has been recorded.
+func (p *DeliveryConfigProcessor) loadPublished(appName string) (*yaml.Node, error) {
+	if p.publishedDir == "" {
+		return nil, nil
+	}
+	path := p.publishedPath(appName)
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, xerrors.Errorf("failed to read %s: %w", path, err)
+	}
+	node := &yaml.Node{}
+	err = yaml.Unmarshal(content, node)
+	if err != nil {
+		return nil, xerrors.Errorf("Failed to parse contents of %s as yaml: %w", path, ErrorInvalidContent{Content: content, ParseError: err})
+	}
+	return node, nil
+}
+
 // ResourceDiff contains the exact records that differ
 type ResourceDiff struct {
 	State   string `json:"state" yaml:"state"`
@@ -804,6 +1037,11 @@ type ManagedResourceDiff struct {
 // the Spinnaker application and report any changes.  This can also be used to validate
 // a delivery config (errors will be returned when an invalid delivery config is submitted).
 func (p *DeliveryConfigProcessor) Diff(cli *Client) ([]*ManagedResourceDiff, error) {
+	return p.DiffContext(context.Background(), cli)
+}
+
+// DiffContext is like Diff but the request is bound to ctx.
+func (p *DeliveryConfigProcessor) DiffContext(ctx context.Context, cli *Client) ([]*ManagedResourceDiff, error) {
 	if len(p.content) == 0 {
 		err := p.Load()
 		if err != nil {
@@ -811,9 +1049,10 @@ func (p *DeliveryConfigProcessor) Diff(cli *Client) ([]*ManagedResourceDiff, err
 		}
 	}
 
-	content, err := commonRequest(cli, "POST", "/managed/delivery-configs/diff", requestBody{
-		Content:     bytes.NewReader(p.content),
-		ContentType: "application/x-yaml",
+	content, err := commonRequest(ctx, cli, "POST", "/managed/delivery-configs/diff", requestBody{
+		Content:        bytes.NewReader(p.content),
+		ContentType:    "application/x-yaml",
+		SideEffectFree: true,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("Failed to diff delivery config with spinnaker: %w", err)
@@ -852,16 +1091,73 @@ func (p *DeliveryConfigProcessor) Diff(cli *Client) ([]*ManagedResourceDiff, err
 // Delete will stop the delivery config from being managed, and will cause Spinnaker
 // to remove all historical state about managing this configuration.
 func (p *DeliveryConfigProcessor) Delete(cli *Client) error {
+	return p.DeleteContext(context.Background(), cli)
+}
+
+// DeleteContext is like Delete but the request is bound to ctx.
+func (p *DeliveryConfigProcessor) DeleteContext(ctx context.Context, cli *Client) error {
 	if p.rawDeliveryConfig == nil {
 		err := p.Load()
 		if err != nil {
 			return xerrors.Errorf("Failed to load delivery config: %w", err)
 		}
 	}
-	_, err := commonRequest(cli, "DELETE", "/managed/delivery-configs/"+p.deliveryConfig.Application, requestBody{})
+	_, err := commonRequest(ctx, cli, "DELETE", "/managed/delivery-configs/"+p.deliveryConfig.Application, requestBody{})
 	return err
 }
 
+// PauseEnvironment will cause Spinnaker to pause managing all the resources in the
+// environment envName, while other environments continue to be managed.  If some of the
+// resources cannot be paused the rest are still paused and an ErrorPartialUpdate is returned.
+func (p *DeliveryConfigProcessor) PauseEnvironment(cli *Client, envName string) error {
+	return p.PauseEnvironmentContext(context.Background(), cli, envName)
+}
+
+// PauseEnvironmentContext is like PauseEnvironment but the requests are bound to ctx.
+func (p *DeliveryConfigProcessor) PauseEnvironmentContext(ctx context.Context, cli *Client, envName string) error {
+	return p.pauseEnvironment(ctx, cli, envName, PauseResourceContext)
+}
+
+// ResumeEnvironment will cause Spinnaker to resume managing all the resources in the
+// environment envName, assuming they had been previously paused.  If some of the resources
+// cannot be resumed the rest are still resumed and an ErrorPartialUpdate is returned.
+func (p *DeliveryConfigProcessor) ResumeEnvironment(cli *Client, envName string) error {
+	return p.ResumeEnvironmentContext(context.Background(), cli, envName)
+}
+
+// ResumeEnvironmentContext is like ResumeEnvironment but the requests are bound to ctx.
+func (p *DeliveryConfigProcessor) ResumeEnvironmentContext(ctx context.Context, cli *Client, envName string) error {
+	return p.pauseEnvironment(ctx, cli, envName, ResumeResourceContext)
+}
+
+func (p *DeliveryConfigProcessor) pauseEnvironment(ctx context.Context, cli *Client, envName string, action func(context.Context, *Client, string) error) error {
+	if p.rawDeliveryConfig == nil {
+		err := p.Load()
+		if err != nil {
+			return xerrors.Errorf("Failed to load delivery config: %w", err)
+		}
+	}
+	resources, err := p.EnvironmentResources(envName)
+	if err != nil {
+		return err
+	}
+	// keep going after failures so the caller knows the state of every resource
+	result := ErrorPartialUpdate{}
+	for _, resource := range resources {
+		err := action(ctx, cli, resource.ID())
+		if err != nil {
+			result.Failed = append(result.Failed, resource.ID())
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		result.Succeeded = append(result.Succeeded, resource.ID())
+	}
+	if len(result.Failed) > 0 {
+		return result
+	}
+	return nil
+}
+
 // ValidationErrorDetail is the structure of the document from /managed/delivery-configs/validate API
 type ValidationErrorDetail struct {
 	Status  int    `json:"severity"`
@@ -871,6 +1167,11 @@ type ValidationErrorDetail struct {
 // Validate posts the delivery config to the validation api and returns nil on success,
 // or a ValidationErrorDetail
 func (p *DeliveryConfigProcessor) Validate(cli *Client) ([]*ValidationErrorDetail, error) {
+	return p.ValidateContext(context.Background(), cli)
+}
+
+// ValidateContext is like Validate but the request is bound to ctx.
+func (p *DeliveryConfigProcessor) ValidateContext(ctx context.Context, cli *Client) ([]*ValidationErrorDetail, error) {
 	if len(p.content) == 0 {
 		err := p.Load()
 		if err != nil {
@@ -878,9 +1179,10 @@ func (p *DeliveryConfigProcessor) Validate(cli *Client) ([]*ValidationErrorDetai
 		}
 	}
 
-	response, err := commonRequest(cli, "POST", "/managed/delivery-configs/validate?validate-all=true", requestBody{
-		Content:     bytes.NewReader(p.content),
-		ContentType: "application/x-yaml",
+	response, err := commonRequest(ctx, cli, "POST", "/managed/delivery-configs/validate?validate-all=true", requestBody{
+		Content:        bytes.NewReader(p.content),
+		ContentType:    "application/x-yaml",
+		SideEffectFree: true,
 	})
 	if err != nil {
 		var errResp ErrorUnexpectedResponse
@@ -910,6 +1212,11 @@ func (p *DeliveryConfigProcessor) Validate(cli *Client) ([]*ValidationErrorDetai
 
 // Plan sends the delivery config to Spinnaker to get the actuation plan
 func (p *DeliveryConfigProcessor) Plan(cli *Client) (*ActuationPlan, error) {
+	return p.PlanContext(context.Background(), cli)
+}
+
+// PlanContext is like Plan but the request is bound to ctx.
+func (p *DeliveryConfigProcessor) PlanContext(ctx context.Context, cli *Client) (*ActuationPlan, error) {
 	if len(p.content) == 0 {
 		err := p.Load()
 		if err != nil {
@@ -917,9 +1224,10 @@ func (p *DeliveryConfigProcessor) Plan(cli *Client) (*ActuationPlan, error) {
 		}
 	}
 
-	content, err := commonRequest(cli, "POST", "/managed/delivery-configs/actuation-plan", requestBody{
-		Content:     bytes.NewReader(p.content),
-		ContentType: "application/x-yaml",
+	content, err := commonRequest(ctx, cli, "POST", "/managed/delivery-configs/actuation-plan", requestBody{
+		Content:        bytes.NewReader(p.content),
+		ContentType:    "application/x-yaml",
+		SideEffectFree: true,
 	})
 	if err != nil {
 		return nil, xerrors.Errorf("Failed to calculate actuation plan: %w", err)
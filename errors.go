@@ -2,19 +2,127 @@ package mdlib
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 )
 
+var (
+	// ErrNotFound matches ErrorUnexpectedResponse errors for 404 responses via errors.Is
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized matches ErrorUnexpectedResponse errors for 401 responses via errors.Is
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden matches ErrorUnexpectedResponse errors for 403 responses via errors.Is
+	ErrForbidden = errors.New("forbidden")
+	// ErrConflict matches ErrorUnexpectedResponse errors for 409 responses via errors.Is
+	ErrConflict = errors.New("conflict")
+	// ErrValidationFailed matches ErrorUnexpectedResponse errors for 400 and 422 responses via errors.Is
+	ErrValidationFailed = errors.New("validation failed")
+	// ErrServerError matches ErrorUnexpectedResponse errors for 5xx responses via errors.Is
+	ErrServerError = errors.New("server error")
+)
+
+// ErrorEnvelope is the error document returned by Spinnaker.  When Gate proxies an error from
+// Keel the original Keel error is JSON encoded as a string in the body property, it will be
+// decoded into Body.
+type ErrorEnvelope struct {
+	Status  int            `json:"status"`
+	Error   string         `json:"error"`
+	Message string         `json:"message"`
+	URL     string         `json:"url"`
+	Body    *ErrorEnvelope `json:"body"`
+}
+
+// type wrapper to prevent recursive unmarshalling with our custom
+// UnmarshalJSON implementation
+type jsonErrorEnvelope ErrorEnvelope
+
+// UnmarshalJSON satisfies the json.Unmarshaller, the body property can be
+// either a nested document or a string containing an encoded document.
+func (e *ErrorEnvelope) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		if err := json.Unmarshal([]byte(s), (*jsonErrorEnvelope)(e)); err != nil {
+			// not an encoded document, so assume it is just a message
+			*e = ErrorEnvelope{Message: s}
+		}
+		return nil
+	}
+	return json.Unmarshal(b, (*jsonErrorEnvelope)(e))
+}
+
+// NestedMessage returns the message from the most deeply nested error, which is
+// typically the most useful message for users.
+func (e *ErrorEnvelope) NestedMessage() string {
+	if e == nil {
+		return ""
+	}
+	if msg := e.Body.NestedMessage(); msg != "" {
+		return msg
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Error
+}
+
 // ErrorUnexpectedResponse will capture request details upon error.
 type ErrorUnexpectedResponse struct {
 	StatusCode int
 	URL        string
 	Content    []byte
+	// Envelope is the decoded error document, it will be nil if the content
+	// was not a Spinnaker error document.
+	Envelope *ErrorEnvelope
+}
+
+func newErrorUnexpectedResponse(statusCode int, u string, content []byte) ErrorUnexpectedResponse {
+	e := ErrorUnexpectedResponse{
+		StatusCode: statusCode,
+		URL:        u,
+		Content:    content,
+	}
+	envelope := &ErrorEnvelope{}
+	if err := json.Unmarshal(content, envelope); err == nil {
+		e.Envelope = envelope
+	}
+	return e
 }
 
 // Error returns the error message
 func (e ErrorUnexpectedResponse) Error() string {
-	return fmt.Sprintf("Unexpected response from %s, expected 200 or 201 but got %d", e.URL, e.StatusCode)
+	msg := fmt.Sprintf("Unexpected response from %s, expected 200 or 201 but got %d", e.URL, e.StatusCode)
+	if detail := e.Message(); detail != "" {
+		msg += ": " + detail
+	}
+	return msg
+}
+
+// Message returns the nested error message from the Spinnaker error document,
+// or an empty string if the response was not an error document.
+func (e ErrorUnexpectedResponse) Message() string {
+	return e.Envelope.NestedMessage()
+}
+
+// Is allows errors.Is to match the response status code against ErrNotFound,
+// ErrUnauthorized, ErrForbidden, ErrConflict, ErrValidationFailed and ErrServerError.
+func (e ErrorUnexpectedResponse) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidationFailed:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrServerError:
+		return e.StatusCode >= 500 && e.StatusCode <= 599
+	}
+	return false
 }
 
 // Parse will attempt to populate the data from the content of the failed request.
@@ -32,3 +140,35 @@ type ErrorInvalidContent struct {
 func (e ErrorInvalidContent) Error() string {
 	return e.ParseError.Error()
 }
+
+// ErrorPartialUpdate is returned when an update applied to several resources, like pausing
+// all the resources in an environment, failed for some of them.  The update was applied to
+// the Succeeded resources, Errors has the error for each of the Failed resources.
+type ErrorPartialUpdate struct {
+	Succeeded []string
+	Failed    []string
+	Errors    []error
+}
+
+// Error returns the failed and succeeded resources with the error for each failure.
+func (e ErrorPartialUpdate) Error() string {
+	failures := []string{}
+	for i, id := range e.Failed {
+		failures = append(failures, fmt.Sprintf("%s: %s", id, e.Errors[i]))
+	}
+	return fmt.Sprintf(
+		"failed to update %d of %d resources: %s; updated resources: [%s]",
+		len(e.Failed), len(e.Failed)+len(e.Succeeded),
+		strings.Join(failures, "; "),
+		strings.Join(e.Succeeded, ", "),
+	)
+}
+
+// Unwrap returns the error for the first failed resource so it can be matched with
+// errors.Is and errors.As.
+func (e ErrorPartialUpdate) Unwrap() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e.Errors[0]
+}
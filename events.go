@@ -0,0 +1,53 @@
+package mdlib
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ResourceEvent is an entry in the management history of a resource, like
+// ResourceDeltaDetected, ResourceActuationLaunched or ResourceCheckError.
+type ResourceEvent struct {
+	Type        string    `json:"type" yaml:"type"`
+	ID          string    `json:"id" yaml:"id"`
+	Kind        string    `json:"kind,omitempty" yaml:"kind,omitempty"`
+	Application string    `json:"application,omitempty" yaml:"application,omitempty"`
+	Timestamp   time.Time `json:"timestamp" yaml:"timestamp"`
+	// Message and Reason are set for failures and other events that need
+	// an explanation.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	// Delta is set for ResourceDeltaDetected events, it describes the
+	// differences between the desired and current state.
+	Delta map[string]interface{} `json:"delta,omitempty" yaml:"delta,omitempty"`
+	// Tasks are set for ResourceActuationLaunched events.
+	Tasks []ResourceTask `json:"tasks,omitempty" yaml:"tasks,omitempty"`
+}
+
+// ResourceTask is a Spinnaker task launched to actuate a resource
+type ResourceTask struct {
+	ID   string `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// GetResourceEvents returns the most recent management events for the resource, newest
+// first.  At most limit events are returned, the Spinnaker default is used if limit is 0.
+func GetResourceEvents(cli *Client, resourceID string, limit int) ([]ResourceEvent, error) {
+	return GetResourceEventsContext(context.Background(), cli, resourceID, limit)
+}
+
+// GetResourceEventsContext is like GetResourceEvents but the request is bound to ctx.
+func GetResourceEventsContext(ctx context.Context, cli *Client, resourceID string, limit int) ([]ResourceEvent, error) {
+	u := fmt.Sprintf("/managed/resources/events/%s", url.PathEscape(resourceID))
+	if limit > 0 {
+		u += fmt.Sprintf("?limit=%d", limit)
+	}
+	events := []ResourceEvent{}
+	err := commonParsedGet(ctx, cli, u, &events)
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
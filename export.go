@@ -1,8 +1,8 @@
 package mdlib
 
 import (
+	"context"
 	"fmt"
-	"regexp"
 	"strings"
 
 	"golang.org/x/sync/errgroup"
@@ -28,6 +28,8 @@ const (
 	AWSCloudProvider = "aws"
 	// TitusCloudProvider is the keyword used to classify that a resource is intended for Titus
 	TitusCloudProvider = "titus"
+	// KubernetesCloudProvider is the keyword used to classify that a resource is intended for Kubernetes
+	KubernetesCloudProvider = "kubernetes"
 
 	// DebianArtifactType is the keyword to to classify a debian artifact
 	DebianArtifactType = "deb"
@@ -36,27 +38,39 @@ const (
 )
 
 // ExportableResource is structure to contain the necessary information to uniquely identify a resource stored
-// in the delivery config or to export from Spinnaker API.
+// in the delivery config or to export from Spinnaker API.  Use field names when creating an ExportableResource,
+// fields are added as cloud providers need them, like Namespace for kubernetes.
 type ExportableResource struct {
 	ResourceType  string
 	CloudProvider string
 	Account       string
 	Name          string
+	// Namespace is only set for resources that are unique to a namespace in the
+	// account, like kubernetes manifests.
+	Namespace string
 }
 
 // String returns a useful formatting string to display an ExportableResource.
 func (r ExportableResource) String() string {
+	if r.Namespace != "" {
+		return fmt.Sprintf("%s %s [%s/%s/%s]", r.ResourceType, r.Name, r.CloudProvider, r.Account, r.Namespace)
+	}
 	return fmt.Sprintf("%s %s [%s/%s]", r.ResourceType, r.Name, r.CloudProvider, r.Account)
 }
 
 // HasKind will return true if the resource matches the provided kind.
 func (r ExportableResource) HasKind(kind string) bool {
-	kindProvider := r.CloudProvider
-	if kindProvider == "aws" {
-		kindProvider = "ec2"
+	kindPrefixes := []string{r.CloudProvider}
+	if provider, ok := LookupCloudProvider(r.CloudProvider); ok {
+		kindPrefixes = provider.kindPrefixes()
+	}
+	for _, kindPrefix := range kindPrefixes {
+		// does it match ec2/cluster@v1
+		if strings.HasPrefix(kind, fmt.Sprintf("%s/%s@", kindPrefix, r.ResourceType)) {
+			return true
+		}
 	}
-	// does it match ec2/cluster@v1
-	return strings.HasPrefix(kind, fmt.Sprintf("%s/%s@", kindProvider, r.ResourceType))
+	return false
 }
 
 // ResourceSorter is a wrapper to help sort ExportableResources
@@ -79,7 +93,10 @@ func (s ResourceSorter) Less(i, j int) bool {
 	if s[i].CloudProvider != s[j].CloudProvider {
 		return s[i].CloudProvider < s[j].CloudProvider
 	}
-	return s[i].Account < s[j].Account
+	if s[i].Account != s[j].Account {
+		return s[i].Account < s[j].Account
+	}
+	return s[i].Namespace < s[j].Namespace
 }
 
 // ArtifactSorter is a wrapper to help sort DeliveryArtifacts
@@ -109,24 +126,51 @@ type ApplicationResources struct {
 	ServerGroups   []ServerGroup
 	LoadBalancers  []LoadBalancer
 	SecurityGroups []SecurityGroup
+	// Ownership decides which load balancers and security groups belong to the
+	// application, DefaultOwnershipPolicy is used when nil.
+	Ownership *OwnershipPolicy `json:"-"`
+}
+
+// Owns returns true if the resource with name, and Spinnaker application metadata
+// application, belongs to the application according to the Ownership policy.
+func (a *ApplicationResources) Owns(name, application string) bool {
+	if a.Ownership == nil {
+		return DefaultOwnershipPolicy(a.AppName).Owns(name, application)
+	}
+	return a.Ownership.Owns(name, application)
 }
 
 // FindApplicationResources will collect application resources from various Spinnaker REST
 // APIs, loading resources in parallel when possible.
 func FindApplicationResources(cli *Client, appName string) (*ApplicationResources, error) {
-	var g errgroup.Group
+	return FindApplicationResourcesContext(context.Background(), cli, appName)
+}
+
+// FindApplicationResourcesContext is like FindApplicationResources but all requests are bound
+// to ctx.  If any request fails the remaining in-flight requests are canceled.
+func FindApplicationResourcesContext(ctx context.Context, cli *Client, appName string) (*ApplicationResources, error) {
+	return FindApplicationResourcesFromAPI(ctx, cli, appName)
+}
+
+// FindApplicationResourcesFromAPI is like FindApplicationResourcesContext but collects the
+// resources from any ManagedDeliveryAPI implementation.
+func FindApplicationResourcesFromAPI(ctx context.Context, api ManagedDeliveryAPI, appName string) (*ApplicationResources, error) {
+	g, ctx := errgroup.WithContext(ctx)
 	data := &ApplicationResources{
 		AppName: appName,
 	}
 
-	g.Go(func() error {
-		return GetServerGroups(cli, appName, &data.ServerGroups)
+	g.Go(func() (err error) {
+		data.ServerGroups, err = api.ServerGroups(ctx, appName)
+		return err
 	})
-	g.Go(func() error {
-		return GetLoadBalancers(cli, appName, &data.LoadBalancers)
+	g.Go(func() (err error) {
+		data.LoadBalancers, err = api.LoadBalancers(ctx, appName)
+		return err
 	})
-	g.Go(func() error {
-		return GetSecurityGroups(cli, appName, &data.SecurityGroups)
+	g.Go(func() (err error) {
+		data.SecurityGroups, err = api.SecurityGroups(ctx, appName)
+		return err
 	})
 
 	err := g.Wait()
@@ -138,33 +182,33 @@ func FindApplicationResources(cli *Client, appName string) (*ApplicationResource
 }
 
 // ExportableApplicationResources will return a list of ExportableResources that
-// are found from the currently deployed application resources.
+// are found from the currently deployed application resources by the scanner of
+// each registered CloudProvider.  Clusters for server groups from providers that are
+// not registered are included so they can be exported with the Spinnaker export APIs.
 func ExportableApplicationResources(appData *ApplicationResources) []*ExportableResource {
 	uniqResources := map[ExportableResource]struct{}{}
 
-	for _, asg := range appData.ServerGroups {
-		uniqResources[ExportableResource{ClusterResourceType, asg.Type, asg.Account, asg.Moniker.Cluster}] = struct{}{}
-	}
-
-	for _, lb := range appData.LoadBalancers {
-		resourceType := LoadBalancerResourceType
-		if len(lb.TargetGroups) > 0 {
-			if lb.LoadBalancerType == "network" {
-				resourceType = NetworkLoadBalancerResourceType
-			} else {
-				resourceType = ApplicationLoadBalancerResourceType
-			}
+	for _, provider := range RegisteredCloudProviders() {
+		if provider.Scanner == nil {
+			continue
 		}
-
-		// only export things by default that look like the belong to this app
-		if matchAppName(appData.AppName, lb.Name) {
-			uniqResources[ExportableResource{resourceType, lb.Type, lb.Account, lb.Name}] = struct{}{}
+		for _, resource := range provider.Scanner(appData) {
+			if provider.SupportsResourceType(resource.ResourceType) {
+				uniqResources[*resource] = struct{}{}
+			}
 		}
 	}
 
-	for _, sg := range appData.SecurityGroups {
-		if matchAppName(appData.AppName, sg.Name) {
-			uniqResources[ExportableResource{SecurityGroupResourceType, AWSCloudProvider, sg.Account, sg.Name}] = struct{}{}
+	// clusters from providers that are not registered are still offered, they are
+	// exported with the Spinnaker export APIs
+	for _, asg := range appData.ServerGroups {
+		if _, ok := LookupCloudProvider(asg.Type); !ok {
+			uniqResources[ExportableResource{
+				ResourceType:  ClusterResourceType,
+				CloudProvider: asg.Type,
+				Account:       asg.Account,
+				Name:          asg.Moniker.Cluster,
+			}] = struct{}{}
 		}
 	}
 
@@ -179,7 +223,12 @@ func ExportableApplicationResources(appData *ApplicationResources) []*Exportable
 // ExportResource will contact the Spinnaker REST API to collect the YAML delivery config representation for
 // a specific resource.
 func ExportResource(cli *Client, resource *ExportableResource) ([]byte, error) {
-	return commonRequest(cli, "GET",
+	return ExportResourceContext(context.Background(), cli, resource)
+}
+
+// ExportResourceContext is like ExportResource but the request is bound to ctx.
+func ExportResourceContext(ctx context.Context, cli *Client, resource *ExportableResource) ([]byte, error) {
+	return commonRequest(ctx, cli, "GET",
 		fmt.Sprintf("/managed/resources/export/%s/%s/%s/%s",
 			resource.CloudProvider,
 			resource.Account,
@@ -193,7 +242,12 @@ func ExportResource(cli *Client, resource *ExportableResource) ([]byte, error) {
 // ExportArtifact will contact the Spinnaker REST API to collect the YAML delivery config representation for
 // the artifacts for the given cluster
 func ExportArtifact(cli *Client, resource *ExportableResource, result interface{}) error {
-	content, err := commonRequest(cli, "GET",
+	return ExportArtifactContext(context.Background(), cli, resource, result)
+}
+
+// ExportArtifactContext is like ExportArtifact but the request is bound to ctx.
+func ExportArtifactContext(ctx context.Context, cli *Client, resource *ExportableResource, result interface{}) error {
+	content, err := commonRequest(ctx, cli, "GET",
 		fmt.Sprintf("/managed/resources/export/artifact/%s/%s/%s",
 			resource.CloudProvider,
 			resource.Account,
@@ -210,10 +264,3 @@ func ExportArtifact(cli *Client, resource *ExportableResource, result interface{
 	}
 	return nil
 }
-
-func matchAppName(appName, resourceName string) bool {
-	// regex ^appName(-.*)?$
-	// this will match "appName" or "appName-something", but not "appName2"
-	rx := regexp.MustCompile(fmt.Sprintf(`^%s(-.*)?$`, appName))
-	return rx.MatchString(resourceName)
-}
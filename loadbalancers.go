@@ -1,10 +1,11 @@
 package mdlib
 
 import (
+	"context"
 	"fmt"
 )
 
-// LoadBalancerTargetGroup contains the name of the target group for an ALB
+// LoadBalancerTargetGroup contains the name of the target group for an ALB or NLB
 type LoadBalancerTargetGroup struct {
 	Name string `json:"name"`
 }
@@ -15,21 +16,40 @@ type LoadBalancerServerGroup struct {
 }
 
 // LoadBalancer contains details about a load balancer, TargetGroups
-// will be populated if it is an ALB.  ServerGroups will be populated
+// will be populated if it is an ALB or NLB.  ServerGroups will be populated
 // if it is an ELB.
 type LoadBalancer struct {
 	Name             string                    `json:"name"`
 	Account          string                    `json:"account"`
 	Region           string                    `json:"region"`
 	Type             string                    `json:"type"`
+	Moniker          Moniker                   `json:"moniker"`
 	LoadBalancerType string                    `json:"loadBalancerType"`
 	SecurityGroups   []string                  `json:"securityGroups"`
 	ServerGroups     []LoadBalancerServerGroup `json:"serverGroups"`
 	TargetGroups     []LoadBalancerTargetGroup `json:"targetGroups"`
 }
 
+// ResourceType returns the resource type used to export the load balancer.  Network
+// load balancers are identified by LoadBalancerType since they may not have any target
+// groups yet, otherwise load balancers with target groups are application load balancers.
+func (lb LoadBalancer) ResourceType() string {
+	switch {
+	case lb.LoadBalancerType == "network":
+		return NetworkLoadBalancerResourceType
+	case lb.LoadBalancerType == "application" || len(lb.TargetGroups) > 0:
+		return ApplicationLoadBalancerResourceType
+	}
+	return LoadBalancerResourceType
+}
+
 // GetLoadBalancers populates the load balancers result structure for spinnaker application appName.
 // Unless a custom result type is required, *[]LoadBalancer is recommended.
 func GetLoadBalancers(cli *Client, appName string, result interface{}) error {
-	return commonParsedGet(cli, fmt.Sprintf("/applications/%s/loadBalancers", appName), result)
+	return GetLoadBalancersContext(context.Background(), cli, appName, result)
+}
+
+// GetLoadBalancersContext is like GetLoadBalancers but the request is bound to ctx.
+func GetLoadBalancersContext(ctx context.Context, cli *Client, appName string, result interface{}) error {
+	return commonParsedGet(ctx, cli, fmt.Sprintf("/applications/%s/loadBalancers", appName), result)
 }
@@ -1,15 +1,52 @@
 package mdlib
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
 
 // PauseManagement will cause Spinnaker to pause managing the state of the application.  Management history will be reserved and can be resumed later.
 func PauseManagement(cli *Client, appName string) error {
-	_, err := commonRequest(cli, "POST", fmt.Sprintf("/managed/application/%s/pause", appName), requestBody{})
+	return PauseManagementContext(context.Background(), cli, appName)
+}
+
+// PauseManagementContext is like PauseManagement but the request is bound to ctx.
+func PauseManagementContext(ctx context.Context, cli *Client, appName string) error {
+	_, err := commonRequest(ctx, cli, "POST", fmt.Sprintf("/managed/application/%s/pause", appName), requestBody{})
 	return err
 }
 
 // ResumeManagement will cause Spinnaker to resume managing the state of the application, assuming it had been previously paused.
 func ResumeManagement(cli *Client, appName string) error {
-	_, err := commonRequest(cli, "DELETE", fmt.Sprintf("/managed/application/%s/pause", appName), requestBody{})
+	return ResumeManagementContext(context.Background(), cli, appName)
+}
+
+// ResumeManagementContext is like ResumeManagement but the request is bound to ctx.
+func ResumeManagementContext(ctx context.Context, cli *Client, appName string) error {
+	_, err := commonRequest(ctx, cli, "DELETE", fmt.Sprintf("/managed/application/%s/pause", appName), requestBody{})
+	return err
+}
+
+// PauseResource will cause Spinnaker to pause managing a single resource, like
+// `ec2:cluster:test:myapp`, while the rest of the application continues to be managed.
+func PauseResource(cli *Client, resourceID string) error {
+	return PauseResourceContext(context.Background(), cli, resourceID)
+}
+
+// PauseResourceContext is like PauseResource but the request is bound to ctx.
+func PauseResourceContext(ctx context.Context, cli *Client, resourceID string) error {
+	_, err := commonRequest(ctx, cli, "POST", fmt.Sprintf("/managed/resources/%s/pause", url.PathEscape(resourceID)), requestBody{})
+	return err
+}
+
+// ResumeResource will cause Spinnaker to resume managing a single resource, assuming it had been previously paused.
+func ResumeResource(cli *Client, resourceID string) error {
+	return ResumeResourceContext(context.Background(), cli, resourceID)
+}
+
+// ResumeResourceContext is like ResumeResource but the request is bound to ctx.
+func ResumeResourceContext(ctx context.Context, cli *Client, resourceID string) error {
+	_, err := commonRequest(ctx, cli, "DELETE", fmt.Sprintf("/managed/resources/%s/pause", url.PathEscape(resourceID)), requestBody{})
 	return err
 }
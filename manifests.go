@@ -0,0 +1,43 @@
+package mdlib
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Manifest is a kubernetes manifest deployed by spinnaker along with the artifacts
+// bound to it, like the container images.
+type Manifest struct {
+	Account   string                 `json:"account"`
+	Location  string                 `json:"location"`
+	Name      string                 `json:"name"`
+	Manifest  map[string]interface{} `json:"manifest"`
+	Moniker   Moniker                `json:"moniker"`
+	Artifacts []ManifestArtifact     `json:"artifacts"`
+}
+
+// ManifestArtifact is an artifact used by a kubernetes manifest, for container images
+// Type is `docker/image` and Reference is the full image name with the tag.
+type ManifestArtifact struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Reference string `json:"reference"`
+	Version   string `json:"version"`
+}
+
+// GetManifest populates the manifest result structure for the kubernetes manifest in the
+// spinnaker account and namespace.  The name includes the kubernetes kind, like
+// `deployment myapp`.  Unless a custom result type is required, *Manifest is recommended.
+func GetManifest(cli *Client, account, namespace, name string, result interface{}) error {
+	return GetManifestContext(context.Background(), cli, account, namespace, name, result)
+}
+
+// GetManifestContext is like GetManifest but the request is bound to ctx.
+func GetManifestContext(ctx context.Context, cli *Client, account, namespace, name string, result interface{}) error {
+	return commonParsedGet(ctx, cli, fmt.Sprintf("/manifests/%s/%s/%s",
+		url.PathEscape(account),
+		url.PathEscape(namespace),
+		url.PathEscape(name),
+	), result)
+}
@@ -0,0 +1,165 @@
+package mdcli
+
+import (
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+)
+
+// DefaultConstraintType is the constraint type judged by the Approve command when
+// ApproveOptions.Type is not set.
+const DefaultConstraintType = "manual-judgement"
+
+// ApproveOptions allows for optional flags to the Approve command.
+type ApproveOptions struct {
+	// Environment, Version and Reference select the pending constraint to judge.
+	// The user is only prompted when none are set and stdin is a terminal,
+	// otherwise they must select exactly one pending constraint.
+	Environment string
+	Version     string
+	Reference   string
+	// Type is the constraint type to judge, defaults to DefaultConstraintType.
+	Type string
+	// Reject will reject the constraint instead of approving it.
+	Reject  bool
+	Comment string
+}
+
+// Approve is a command line interface to approve or reject a pending constraint, like
+// manual-judgement, for the application from the local delivery config.  The user is
+// prompted to pick from the pending constraints when stdin is a terminal and nothing
+// is selected in approveOpts, otherwise it is an error unless exactly one pending
+// constraint matches.
+func Approve(opts *CommandOptions, approveOpts ApproveOptions) (int, error) {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return 1, err
+	}
+	appName := mdProcessor.Application()
+	if appName == "" {
+		return 1, xerrors.Errorf("application not found in %s", opts.ConfigFile)
+	}
+	if approveOpts.Type == "" {
+		approveOpts.Type = DefaultConstraintType
+	}
+
+	cli := opts.newClient()
+
+	envNames, err := selectEnvironments(mdProcessor, approveOpts.Environment)
+	if err != nil {
+		return 1, err
+	}
+
+	pending, err := mdlib.PendingConstraintsContext(opts.ctx(), cli, appName, envNames)
+	if err != nil {
+		return 1, err
+	}
+	candidates := []mdlib.ConstraintState{}
+	for _, state := range pending {
+		if state.Type != approveOpts.Type {
+			continue
+		}
+		if approveOpts.Version != "" && state.ArtifactVersion != approveOpts.Version {
+			continue
+		}
+		if approveOpts.Reference != "" && state.ArtifactReference != approveOpts.Reference {
+			continue
+		}
+		candidates = append(candidates, state)
+	}
+
+	var selected mdlib.ConstraintState
+	reject := approveOpts.Reject
+	selecting := approveOpts.Environment != "" || approveOpts.Version != "" || approveOpts.Reference != ""
+	if selecting || !opts.interactive() {
+		switch len(candidates) {
+		case 0:
+			return 1, xerrors.Errorf("no pending %s constraint found%s", approveOpts.Type, describeSelection(approveOpts))
+		case 1:
+			selected = candidates[0]
+		default:
+			found := []string{}
+			for _, state := range candidates {
+				found = append(found, state.String())
+			}
+			return 1, xerrors.Errorf(
+				"%d pending %s constraints found%s, the environment, version and artifact reference must select one: %s",
+				len(candidates), approveOpts.Type, describeSelection(approveOpts), strings.Join(found, ", "),
+			)
+		}
+	} else {
+		if len(candidates) == 0 {
+			opts.Logger.Noticef("No pending %s constraints", approveOpts.Type)
+			return 0, nil
+		}
+		options := []string{}
+		byOption := map[string]mdlib.ConstraintState{}
+		for _, state := range candidates {
+			options = append(options, state.String())
+			byOption[state.String()] = state
+		}
+		choice := ""
+		err = survey.AskOne(
+			&survey.Select{
+				Message: "Select constraint to judge",
+				Options: options,
+			},
+			&choice,
+			survey.WithStdio(opts.Stdin, opts.Stdout, opts.Stderr),
+		)
+		if err != nil {
+			return 1, err
+		}
+		selected = byOption[choice]
+
+		if !reject {
+			action := ""
+			err = survey.AskOne(
+				&survey.Select{
+					Message: "Judgement",
+					Options: []string{"approve", "reject"},
+				},
+				&action,
+				survey.WithStdio(opts.Stdin, opts.Stdout, opts.Stderr),
+			)
+			if err != nil {
+				return 1, err
+			}
+			reject = action == "reject"
+		}
+	}
+
+	if reject {
+		err = mdlib.RejectConstraintContext(opts.ctx(), cli, appName, selected, approveOpts.Comment)
+	} else {
+		err = mdlib.ApproveConstraintContext(opts.ctx(), cli, appName, selected, approveOpts.Comment)
+	}
+	if err != nil {
+		return 1, err
+	}
+
+	if reject {
+		opts.Logger.Noticef("Rejected %s", selected)
+	} else {
+		opts.Logger.Noticef("Approved %s", selected)
+	}
+	return 0, nil
+}
+
+// describeSelection returns the constraint selection from approveOpts for error
+// messages, like ` for version myapp-1.0.0 in testing`.
+func describeSelection(approveOpts ApproveOptions) string {
+	desc := ""
+	if approveOpts.Reference != "" {
+		desc += " for artifact " + approveOpts.Reference
+	}
+	if approveOpts.Version != "" {
+		desc += " for version " + approveOpts.Version
+	}
+	if approveOpts.Environment != "" {
+		desc += " in " + approveOpts.Environment
+	}
+	return desc
+}
@@ -0,0 +1,98 @@
+package mdcli
+
+import (
+	"os"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApprove(t *testing.T) {
+	md := mdfake.New()
+	for _, version := range []string{"myapp-1.0.0", "myapp-1.0.1"} {
+		md.AddConstraintState("myapp", mdlib.ConstraintState{
+			EnvironmentName:   "testing",
+			ArtifactReference: "myapp",
+			ArtifactVersion:   version,
+			Type:              "manual-judgement",
+			Status:            mdlib.ConstraintPending,
+		})
+	}
+	opts := newFakeCommandOptions(t, md)
+
+	exitCode, err := Approve(opts, ApproveOptions{
+		Environment: "testing",
+		Version:     "myapp-1.0.0",
+		Comment:     "LGTM",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	exitCode, err = Approve(opts, ApproveOptions{
+		Environment: "testing",
+		Version:     "myapp-1.0.1",
+		Reject:      true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	states := md.ConstraintStates("myapp")
+	require.Equal(t, mdlib.ConstraintOverridePass, states[0].Status)
+	require.Equal(t, "LGTM", states[0].Comment)
+	require.Equal(t, mdlib.ConstraintOverrideFail, states[1].Status)
+
+	// already judged, so nothing is pending
+	_, err = Approve(opts, ApproveOptions{
+		Environment: "testing",
+		Version:     "myapp-1.0.0",
+	})
+	require.Error(t, err)
+
+	_, err = Approve(opts, ApproveOptions{
+		Environment: "production",
+		Version:     "myapp-1.0.0",
+	})
+	require.Error(t, err)
+}
+
+func TestApproveNonInteractive(t *testing.T) {
+	md := mdfake.New()
+	for _, version := range []string{"myapp-1.0.0", "myapp-1.0.1"} {
+		md.AddConstraintState("myapp", mdlib.ConstraintState{
+			EnvironmentName:   "testing",
+			ArtifactReference: "myapp",
+			ArtifactVersion:   version,
+			Type:              "manual-judgement",
+			Status:            mdlib.ConstraintPending,
+		})
+	}
+	opts := newFakeCommandOptions(t, md)
+	stdin, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer stdin.Close()
+	opts.Stdin = stdin
+
+	// stdin is not a terminal so the user is not prompted
+	exitCode, err := Approve(opts, ApproveOptions{})
+	require.Error(t, err)
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, err.Error(), "2 pending manual-judgement constraints found")
+
+	// the environment alone matches both versions
+	exitCode, err = Approve(opts, ApproveOptions{Environment: "testing"})
+	require.Error(t, err)
+	require.Equal(t, 1, exitCode)
+
+	exitCode, err = Approve(opts, ApproveOptions{Version: "myapp-1.0.2"})
+	require.Error(t, err)
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, err.Error(), "no pending manual-judgement constraint found for version myapp-1.0.2")
+
+	exitCode, err = Approve(opts, ApproveOptions{Version: "myapp-1.0.1"})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, mdlib.ConstraintOverridePass, md.ConstraintStates("myapp")[1].Status)
+	require.Equal(t, mdlib.ConstraintPending, md.ConstraintStates("myapp")[0].Status)
+}
@@ -0,0 +1,83 @@
+package mdcli
+
+import (
+	"context"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+)
+
+// Pin is a command line interface to pin an artifact version in an environment from the
+// local delivery config.  The artifact reference defaults to the only artifact in the
+// delivery config when not set.
+func Pin(opts *CommandOptions, pin mdlib.EnvironmentArtifactVersion) error {
+	return updateArtifactVersion(opts, pin, true, mdlib.PinArtifactVersionContext)
+}
+
+// Unpin is a command line interface to remove the pinned artifact version in an environment
+// from the local delivery config.  The artifact reference defaults to the only artifact in
+// the delivery config when not set.
+func Unpin(opts *CommandOptions, envName, reference string) error {
+	target := mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: envName,
+		Reference:         reference,
+	}
+	return updateArtifactVersion(opts, target, false, func(ctx context.Context, cli *mdlib.Client, appName string, target mdlib.EnvironmentArtifactVersion) error {
+		return mdlib.UnpinArtifactVersionContext(ctx, cli, appName, target.TargetEnvironment, target.Reference)
+	})
+}
+
+// Veto is a command line interface to veto an artifact version in an environment from the
+// local delivery config.  The artifact reference defaults to the only artifact in the
+// delivery config when not set.
+func Veto(opts *CommandOptions, veto mdlib.EnvironmentArtifactVersion) error {
+	return updateArtifactVersion(opts, veto, true, mdlib.VetoArtifactVersionContext)
+}
+
+// MarkBad is a command line interface to mark an artifact version as bad in an environment
+// from the local delivery config.  The artifact reference defaults to the only artifact in
+// the delivery config when not set.
+func MarkBad(opts *CommandOptions, version mdlib.EnvironmentArtifactVersion) error {
+	return updateArtifactVersion(opts, version, true, mdlib.MarkArtifactVersionBadContext)
+}
+
+// MarkGood is a command line interface to remove a veto or bad mark for an artifact version
+// in an environment from the local delivery config.  The artifact reference defaults to the
+// only artifact in the delivery config when not set.
+func MarkGood(opts *CommandOptions, version mdlib.EnvironmentArtifactVersion) error {
+	return updateArtifactVersion(opts, version, true, mdlib.MarkArtifactVersionGoodContext)
+}
+
+func updateArtifactVersion(opts *CommandOptions, target mdlib.EnvironmentArtifactVersion, needVersion bool, update func(context.Context, *mdlib.Client, string, mdlib.EnvironmentArtifactVersion) error) error {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return err
+	}
+	appName := mdProcessor.Application()
+	if appName == "" {
+		return xerrors.Errorf("application not found in %s", opts.ConfigFile)
+	}
+	if target.Reference == "" {
+		target.Reference = mdProcessor.DefaultArtifactReference()
+		if target.Reference == "" {
+			return xerrors.New("artifact reference is required when the delivery config does not have exactly one artifact")
+		}
+	}
+	if needVersion && target.Version == "" {
+		return xerrors.New("artifact version is required")
+	}
+	err = mdProcessor.ValidateArtifactTarget(target.TargetEnvironment, target.Reference)
+	if err != nil {
+		return err
+	}
+
+	cli := opts.newClient()
+
+	err = update(opts.ctx(), cli, appName, target)
+	if err != nil {
+		return err
+	}
+
+	opts.Logger.Noticef("OK")
+	return nil
+}
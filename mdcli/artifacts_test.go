@@ -0,0 +1,80 @@
+package mdcli
+
+import (
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinAndVeto(t *testing.T) {
+	md := mdfake.New()
+	opts := newFakeCommandOptions(t, md)
+
+	_, err := Publish(opts, false)
+	require.NoError(t, err)
+
+	require.NoError(t, Pin(opts, mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Reference:         "myapp",
+		Version:           "myapp-1.0.0",
+		Comment:           "hold for release",
+	}))
+	version, ok := md.Pinned("myapp", "testing", "myapp")
+	require.True(t, ok)
+	require.Equal(t, "myapp-1.0.0", version)
+
+	require.NoError(t, Unpin(opts, "testing", "myapp"))
+	_, ok = md.Pinned("myapp", "testing", "myapp")
+	require.False(t, ok)
+
+	require.NoError(t, Veto(opts, mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Reference:         "myteam/myapp-test",
+		Version:           "1.2.3",
+	}))
+	require.True(t, md.Vetoed("myapp", "testing", "myteam/myapp-test", "1.2.3"))
+
+	// the config has two artifacts so the reference is required
+	require.Error(t, Veto(opts, mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Version:           "1.2.3",
+	}))
+	require.Error(t, Pin(opts, mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "production",
+		Reference:         "myapp",
+		Version:           "myapp-1.0.0",
+	}))
+	require.Error(t, Pin(opts, mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Reference:         "missing",
+		Version:           "myapp-1.0.0",
+	}))
+}
+
+func TestMarkBadAndGood(t *testing.T) {
+	md := mdfake.New()
+	opts := newFakeCommandOptions(t, md)
+
+	_, err := Publish(opts, false)
+	require.NoError(t, err)
+
+	version := mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Reference:         "myapp",
+		Version:           "myapp-1.0.0",
+		Comment:           "broken",
+	}
+	require.NoError(t, MarkBad(opts, version))
+	require.True(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.0"))
+
+	require.NoError(t, MarkGood(opts, version))
+	require.False(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.0"))
+
+	// the version is required
+	require.Error(t, MarkBad(opts, mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Reference:         "myapp",
+	}))
+}
@@ -3,8 +3,6 @@ package mdcli
 import (
 	"os"
 	"path/filepath"
-
-	mdlib "github.com/spinnaker/md-lib-go"
 )
 
 // Delete is a command line interface for removing the management
@@ -15,18 +13,11 @@ func Delete(opts *CommandOptions) error {
 		return err
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
 
-	mdProcessor := mdlib.NewDeliveryConfigProcessor(
-		mdlib.WithDirectory(opts.ConfigDir),
-		mdlib.WithFile(opts.ConfigFile),
-		mdlib.WithLogger(opts.Logger),
-	)
+	mdProcessor := opts.newProcessor()
 
-	err := mdProcessor.Delete(cli)
+	err := mdProcessor.DeleteContext(opts.ctx(), cli)
 	if err != nil {
 		return err
 	}
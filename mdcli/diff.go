@@ -7,7 +7,6 @@ import (
 	"sort"
 
 	"github.com/mgutz/ansi"
-	mdlib "github.com/spinnaker/md-lib-go"
 )
 
 // DiffOptions allows for optional flags to the Diff command.
@@ -26,18 +25,11 @@ func Diff(opts *CommandOptions, diffOpts DiffOptions) (int, error) {
 		return 0, err
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
 
-	mdProcessor := mdlib.NewDeliveryConfigProcessor(
-		mdlib.WithDirectory(opts.ConfigDir),
-		mdlib.WithFile(opts.ConfigFile),
-		mdlib.WithLogger(opts.Logger),
-	)
+	mdProcessor := opts.newProcessor()
 
-	diffs, err := mdProcessor.Diff(cli)
+	diffs, err := mdProcessor.DiffContext(opts.ctx(), cli)
 	if err != nil {
 		return 0, err
 	}
@@ -1,31 +1,85 @@
 package mdcli
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdtest"
 	"github.com/stretchr/testify/require"
 )
 
 func TestDiff(t *testing.T) {
+	ts := mdtest.NewServer(t, "../test-files/diff")
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/diff"
+	opts.ConfigFile = "spinnaker.yml"
+
+	exitCode, err := Diff(opts, DiffOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, exitCode)
+
+	// we expect a single POST to delivery-configs/diff diff API
+	ts.AssertRequests(t, map[string]int{
+		"POST /managed/delivery-configs/diff": 1,
+	})
+}
+
+func TestDiffCanceled(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusOK)
+			},
+		),
+	)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/diff"
+	opts.ConfigFile = "spinnaker.yml"
+	opts.Context = ctx
+
+	_, err := Diff(opts, DiffOptions{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 0, requests)
+}
+
+func TestDiffRetry(t *testing.T) {
 	requests := map[string]int{}
+	bodies := []string{}
 	ts := httptest.NewServer(
 		http.HandlerFunc(
 			func(w http.ResponseWriter, r *http.Request) {
 				requests[fmt.Sprintf("%s %s", r.Method, r.URL.String())]++
-				responsePath := fmt.Sprintf("../test-files/diff/responses%s/%s.json", r.URL.Path, r.Method)
-				w.Header().Set("Content-Type", "application/json")
-				if _, err := os.Stat(responsePath); err != nil {
-					w.WriteHeader(http.StatusNotFound)
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				bodies = append(bodies, string(body))
+				if len(bodies) < 3 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(http.StatusServiceUnavailable)
 					return
 				}
-				fh, err := os.Open(responsePath)
+				fh, err := os.Open("../test-files/diff/responses/managed/delivery-configs/diff/POST.json")
 				require.NoError(t, err)
 				defer fh.Close()
+				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusOK)
 				io.Copy(w, fh)
 			},
@@ -37,13 +91,42 @@ func TestDiff(t *testing.T) {
 	opts.BaseURL = ts.URL
 	opts.ConfigDir = "../test-files/diff"
 	opts.ConfigFile = "spinnaker.yml"
+	opts.ClientOpts = append(opts.ClientOpts, mdlib.WithRetryPolicy(mdlib.RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
 
 	exitCode, err := Diff(opts, DiffOptions{})
 	require.NoError(t, err)
 	require.Equal(t, 1, exitCode)
 
-	// we expect a single POST to delivery-configs/diff diff API
 	require.Equal(t, map[string]int{
-		"POST /managed/delivery-configs/diff": 1,
+		"POST /managed/delivery-configs/diff": 3,
 	}, requests)
+
+	// the delivery config must be re-sent in full on each attempt
+	expected, err := ioutil.ReadFile("../test-files/diff/spinnaker.yml")
+	require.NoError(t, err)
+	for _, body := range bodies {
+		require.Equal(t, string(expected), body)
+	}
+}
+
+func TestDiffNotFound(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/diff"
+	opts.ConfigFile = "spinnaker.yml"
+
+	_, err := Diff(opts, DiffOptions{})
+	require.ErrorIs(t, err, mdlib.ErrNotFound)
+	require.False(t, errors.Is(err, mdlib.ErrServerError))
+
+	var errResp mdlib.ErrorUnexpectedResponse
+	require.ErrorAs(t, err, &errResp)
+	require.Equal(t, http.StatusNotFound, errResp.StatusCode)
 }
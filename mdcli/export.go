@@ -1,16 +1,20 @@
 package mdcli
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"sort"
+	"sync"
+	"time"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/mgutz/ansi"
 	mdlib "github.com/spinnaker/md-lib-go"
 	"github.com/xlab/treeprint"
 	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/xerrors"
 	"gopkg.in/yaml.v3"
 )
@@ -21,8 +25,12 @@ type exportOptions struct {
 	envName                string
 	onlyAccount            string
 	clusters               []string
+	resourcesCache         *mdlib.ApplicationResourcesCache
+	refreshResources       bool
+	concurrency            int
+	ownership              *mdlib.OwnershipPolicy
 	customResourceScanner  func(*mdlib.ApplicationResources) []*mdlib.ExportableResource
-	customResourceExporter func(*mdlib.Client, *mdlib.ExportableResource) ([]byte, error)
+	customResourceExporter func(context.Context, *mdlib.Client, *mdlib.ExportableResource) ([]byte, error)
 	constraintsProvider    func(envName string, current mdlib.DeliveryConfig) []interface{}
 	notificationsProvider  func(envName string, current mdlib.DeliveryConfig) []interface{}
 	verifyWithProvider     func(envName string, current mdlib.DeliveryConfig) []interface{}
@@ -68,8 +76,20 @@ func CustomResourceScanner(f func(*mdlib.ApplicationResources) []*mdlib.Exportab
 }
 
 // CustomResourceExporter is an override to Export that can be used to implement a custom resource exporter.
-// The default exporter is mdlib.ExportResource
+// The default exporter uses the registered mdlib.CloudProvider for the resource.  The exporter is called
+// concurrently for the selected resources, see ExportConcurrency.
 func CustomResourceExporter(f func(*mdlib.Client, *mdlib.ExportableResource) ([]byte, error)) ExportOption {
+	return func(o *exportOptions) {
+		o.customResourceExporter = func(_ context.Context, cli *mdlib.Client, resource *mdlib.ExportableResource) ([]byte, error) {
+			return f(cli, resource)
+		}
+	}
+}
+
+// CustomResourceExporterContext is like CustomResourceExporter but the exporter will be
+// called with the Context from the CommandOptions.
+// The default exporter uses the registered mdlib.CloudProvider for the resource.
+func CustomResourceExporterContext(f func(context.Context, *mdlib.Client, *mdlib.ExportableResource) ([]byte, error)) ExportOption {
 	return func(o *exportOptions) {
 		o.customResourceExporter = f
 	}
@@ -121,28 +141,159 @@ func SetClusters(clusters []string) ExportOption {
 	}
 }
 
+// CacheResources is an override to Export, if a non-nil cache is provided the Spinnaker
+// resources found for the application will be loaded from and stored in the cache.
+func CacheResources(cache *mdlib.ApplicationResourcesCache) ExportOption {
+	return func(o *exportOptions) {
+		o.resourcesCache = cache
+	}
+}
+
+// RefreshResources is an override to Export, when true any cached Spinnaker resources
+// will be ignored and the cache will be updated.
+func RefreshResources(b bool) ExportOption {
+	return func(o *exportOptions) {
+		o.refreshResources = b
+	}
+}
+
+// Ownership is an override to Export to set which load balancers and security groups
+// belong to the application and are offered for export.  The default is
+// mdlib.DefaultOwnershipPolicy for the application.
+func Ownership(policy *mdlib.OwnershipPolicy) ExportOption {
+	return func(o *exportOptions) {
+		o.ownership = policy
+	}
+}
+
+// DefaultExportConcurrency is the number of resources Export will export at a time
+// unless overridden with ExportConcurrency.
+const DefaultExportConcurrency = 8
+
+// ExportConcurrency is an override to Export to set how many of the selected resources,
+// and the artifacts they deploy, are exported at a time.  The delivery config is always
+// updated in the order the resources were selected.  Values less than 1 use
+// DefaultExportConcurrency.
+func ExportConcurrency(n int) ExportOption {
+	return func(o *exportOptions) {
+		o.concurrency = n
+	}
+}
+
+// resourceProvider returns the registered mdlib.CloudProvider for the resource.  Resources
+// from other providers, like those from a CustomResourceScanner, are exported with the
+// Spinnaker export APIs and artifacts are exported for clusters.
+func resourceProvider(resource *mdlib.ExportableResource) *mdlib.CloudProvider {
+	if provider, ok := mdlib.LookupCloudProvider(resource.CloudProvider); ok {
+		return provider
+	}
+	return &mdlib.CloudProvider{
+		Name:                  resource.CloudProvider,
+		ArtifactResourceTypes: []string{mdlib.ClusterResourceType},
+	}
+}
+
+func exportResource(ctx context.Context, cli *mdlib.Client, resource *mdlib.ExportableResource) ([]byte, error) {
+	return resourceProvider(resource).ExportResource(ctx, cli, resource)
+}
+
+// exportResult is the exported delivery config content for a resource and the artifact
+// it deploys, if any.
+type exportResult struct {
+	content     []byte
+	err         error
+	artifact    *mdlib.DeliveryArtifact
+	artifactErr error
+}
+
+// exportResources exports the resources and their artifacts with a bounded number of
+// concurrent requests, logging progress as each resource is done.  The results are in
+// the same order as resources.
+func exportResources(ctx context.Context, opts *CommandOptions, cli *mdlib.Client, exportOpts *exportOptions, resources []*mdlib.ExportableResource) []exportResult {
+	results := make([]exportResult, len(resources))
+
+	concurrency := exportOpts.concurrency
+	if concurrency < 1 {
+		concurrency = DefaultExportConcurrency
+	}
+	g := errgroup.Group{}
+	g.SetLimit(concurrency)
+
+	// progress is logged from the workers, so serialize it for custom loggers
+	var mu sync.Mutex
+	done := 0
+	progress := func(resource *mdlib.ExportableResource, result exportResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		status := "Exported"
+		if result.err != nil || result.artifactErr != nil {
+			status = "Failed to export"
+		}
+		opts.Logger.Printf("%s %s (%d/%d)", status, resource, done, len(resources))
+	}
+
+	opts.Logger.Printf("Exporting %d resources", len(resources))
+	for ix, resource := range resources {
+		if ctx.Err() != nil {
+			break
+		}
+		ix, resource := ix, resource
+		g.Go(func() error {
+			result := &results[ix]
+			result.content, result.err = exportOpts.customResourceExporter(ctx, cli, resource)
+			if result.err != nil {
+				result.err = xerrors.Errorf("Failed to export resource %s: %w", resource, result.err)
+			} else {
+				result.artifact, result.artifactErr = resourceProvider(resource).ExportArtifact(ctx, cli, resource)
+			}
+			progress(resource, *result)
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
+
 // Export is a command line interface to discover exportable Spinnaker resources and then
 // optional add those resources to a local delivery config file to be later managed by Spinnaker.
 func Export(opts *CommandOptions, appName string, overrides ...ExportOption) (int, error) {
 	exportOpts := &exportOptions{
 		customResourceScanner:  mdlib.ExportableApplicationResources,
-		customResourceExporter: mdlib.ExportResource,
+		customResourceExporter: exportResource,
 	}
 	for _, override := range overrides {
 		override(exportOpts)
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
 
 	opts.Logger.Printf("Loading spinnaker resources for %s", appName)
 
-	appData, err := mdlib.FindApplicationResources(cli, appName)
+	ctx := opts.ctx()
+
+	var appData *mdlib.ApplicationResources
+	var err error
+	switch {
+	case exportOpts.resourcesCache == nil:
+		appData, err = mdlib.FindApplicationResourcesContext(ctx, cli, appName)
+	case !exportOpts.refreshResources:
+		// say when cached resources are used so stale results are not a surprise
+		if data, storedAt, ok := exportOpts.resourcesCache.Lookup(cli.BaseURL(), appName); ok {
+			opts.Logger.Noticef("Using spinnaker resources for %s cached %s ago, refresh to load the current resources", appName, time.Since(storedAt).Round(time.Second))
+			appData = data
+			break
+		}
+		fallthrough
+	default:
+		appData, err = mdlib.FindApplicationResourcesCached(ctx, cli, appName, exportOpts.resourcesCache, true)
+	}
 	if err != nil {
 		return 1, err
 	}
+	if exportOpts.ownership != nil {
+		appData.Ownership = exportOpts.ownership
+	}
 
 	exportable := exportOpts.customResourceScanner(appData)
 
@@ -162,15 +313,12 @@ func Export(opts *CommandOptions, appName string, overrides ...ExportOption) (in
 		exportable = filtered
 	}
 
-	mdProcessor := mdlib.NewDeliveryConfigProcessor(
-		mdlib.WithDirectory(opts.ConfigDir),
-		mdlib.WithFile(opts.ConfigFile),
+	mdProcessor := opts.newProcessor(
 		mdlib.WithAppName(appName),
 		mdlib.WithConstraintsProvider(exportOpts.constraintsProvider),
 		mdlib.WithNotificationsProvider(exportOpts.notificationsProvider),
 		mdlib.WithVerifyProvider(exportOpts.verifyWithProvider),
 		mdlib.WithPostDeployProvider(exportOpts.postDeployProvider),
-		mdlib.WithLogger(opts.Logger),
 	)
 
 	err = mdProcessor.Load()
@@ -186,15 +334,8 @@ func Export(opts *CommandOptions, appName string, overrides ...ExportOption) (in
 	defaults := []string{}
 	optionsIndexByName := map[string]int{}
 	for ix, resource := range exportable {
-		var option string
-		switch {
-		case mdProcessor.ResourceExists(resource):
-			option = fmt.Sprintf("Export %s", resource)
-		case resource.ResourceType == mdlib.NetworkLoadBalancerResourceType:
-			opts.Logger.Printf("WARNING cannot export %s", resource)
-			continue
-		default:
-			option = fmt.Sprintf("Export %s", resource)
+		option := fmt.Sprintf("Export %s", resource)
+		if !mdProcessor.ResourceExists(resource) {
 			defaults = append(defaults, option)
 		}
 		options = append(options, option)
@@ -241,19 +382,32 @@ func Export(opts *CommandOptions, appName string, overrides ...ExportOption) (in
 		}
 	}
 
+	selectedResources := []*mdlib.ExportableResource{}
+	for _, selection := range selected {
+		selectedResources = append(selectedResources, exportable[optionsIndexByName[selection]])
+	}
+	results := exportResources(ctx, opts, cli, exportOpts, selectedResources)
+	if err := ctx.Err(); err != nil {
+		return 1, err
+	}
+
 	errors := []error{}
 
+	// apply the results in the order selected so the delivery config and the artifact
+	// references are the same no matter which exports finished first
 	selectedEnvironments := map[string]string{}
 	modifiedResources := map[*mdlib.ExportableResource]bool{}
 	addedArtifacts := []*mdlib.DeliveryArtifact{}
-	for _, selection := range selected {
-		resource := exportable[optionsIndexByName[selection]]
-		opts.Logger.Printf("Exporting %s", resource)
-		content, err := exportOpts.customResourceExporter(cli, resource)
-		if err != nil {
-			errors = append(errors, xerrors.Errorf("Failed to export resource %s: %w", resource, err))
+	for ix, resource := range selectedResources {
+		result := results[ix]
+		if result.err != nil {
+			errors = append(errors, result.err)
 			continue
 		}
+		content := result.content
+		if resourceProvider(resource).Experimental {
+			opts.Logger.Noticef("Exporting %s resources is experimental, review %s before publishing", resource.CloudProvider, resource)
+		}
 
 		envName := exportOpts.envName
 		if envName == "" {
@@ -292,14 +446,11 @@ func Export(opts *CommandOptions, appName string, overrides ...ExportOption) (in
 		}
 		modifiedResources[resource] = added
 
-		if resource.ResourceType == mdlib.ClusterResourceType {
-			opts.Logger.Printf("Exporting Artifact for %s", resource)
-			artifact := &mdlib.DeliveryArtifact{}
-			err := mdlib.ExportArtifact(cli, resource, artifact)
-			if err != nil {
-				errors = append(errors, err)
-				continue
-			}
+		if result.artifactErr != nil {
+			errors = append(errors, result.artifactErr)
+			continue
+		}
+		if artifact := result.artifact; artifact != nil {
 			if added, updatedRef := mdProcessor.InsertArtifact(artifact); added || updatedRef != "" {
 				if added {
 					found := false
@@ -1,47 +1,22 @@
 package mdcli
 
 import (
-	"fmt"
-	"io"
+	"context"
 	"io/ioutil"
-	"net/http"
-	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"testing"
+	"time"
 
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdtest"
 	"github.com/stretchr/testify/require"
 )
 
 func TestExport(t *testing.T) {
-	var mu sync.Mutex
-	requests := map[string]int{}
-	ts := httptest.NewServer(
-		http.HandlerFunc(
-			func(w http.ResponseWriter, r *http.Request) {
-				mu.Lock()
-				requests[fmt.Sprintf("%s %s", r.Method, r.URL.Path)]++
-				mu.Unlock()
-				responsePath := fmt.Sprintf("../test-files/export/responses%s/%s.json", r.URL.Path, r.Method)
-				w.Header().Set("Content-Type", "application/json")
-				if _, err := os.Stat(responsePath); err != nil {
-					responsePath = fmt.Sprintf("../test-files/export/responses%s/%s.yml", r.URL.Path, r.Method)
-					if _, err := os.Stat(responsePath); err != nil {
-						w.WriteHeader(http.StatusNotFound)
-						return
-					}
-					w.Header().Set("Content-Type", "application/x-yaml")
-				}
-				fh, err := os.Open(responsePath)
-				require.NoError(t, err)
-				defer fh.Close()
-				w.WriteHeader(http.StatusOK)
-				io.Copy(w, fh)
-			},
-		),
-	)
-	defer ts.Close()
+	ts := mdtest.NewServer(t, "../test-files/export")
 
 	tdir, err := ioutil.TempDir("", "spinnaker-export")
 	require.NoError(t, err)
@@ -61,17 +36,18 @@ func TestExport(t *testing.T) {
 	require.NoError(t, err)
 
 	// we expect a bunch of GET requests to various APIs
-	require.Equal(t, map[string]int{
-		"GET /applications/myapp/loadBalancers":                          1,
-		"GET /applications/myapp/serverGroups":                           1,
-		"GET /managed/resources/export/artifact/aws/test/myapp":          1,
-		"GET /managed/resources/export/artifact/titus/titustest/myapp":   1,
-		"GET /managed/resources/export/aws/test/cluster/myapp":           1,
-		"GET /managed/resources/export/aws/test/security-group/myapp":    1,
-		"GET /managed/resources/export/aws/dbs/security-group/myapp-rds": 1,
-		"GET /managed/resources/export/titus/titustest/cluster/myapp":    1,
+	ts.AssertRequests(t, map[string]int{
+		"GET /applications/myapp/loadBalancers":                                  1,
+		"GET /applications/myapp/serverGroups":                                   1,
+		"GET /managed/resources/export/artifact/aws/test/myapp":                  1,
+		"GET /managed/resources/export/artifact/titus/titustest/myapp":           1,
+		"GET /managed/resources/export/aws/test/cluster/myapp":                   1,
+		"GET /managed/resources/export/aws/test/security-group/myapp":            1,
+		"GET /managed/resources/export/aws/dbs/security-group/myapp-rds":         1,
+		"GET /managed/resources/export/aws/test/network-load-balancer/myapp-nlb": 1,
+		"GET /managed/resources/export/titus/titustest/cluster/myapp":            1,
 		"GET /search": 1,
-	}, requests)
+	})
 
 	got, err := ioutil.ReadFile(filepath.Join(opts.ConfigDir, opts.ConfigFile))
 	require.NoError(t, err)
@@ -81,3 +57,135 @@ func TestExport(t *testing.T) {
 
 	require.Equal(t, string(expected), string(got))
 }
+
+func TestExportCachedResources(t *testing.T) {
+	ts := mdtest.NewServer(t, "../test-files/export")
+
+	tdir, err := ioutil.TempDir("", "spinnaker-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(tdir)
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = tdir
+	opts.ConfigFile = "spinnaker.yml"
+
+	cache := mdlib.NewApplicationResourcesCache(filepath.Join(tdir, "cache"), time.Hour)
+	for _, refresh := range []bool{false, false, true} {
+		_, err = Export(
+			opts,
+			"myapp",
+			AssumeEnvName("testing"),
+			ExportAll(true),
+			CacheResources(cache),
+			RefreshResources(refresh),
+		)
+		require.NoError(t, err)
+	}
+
+	// application resources are only loaded on the first export and on refresh
+	counts := ts.RequestCounts()
+	require.Equal(t, 2, counts["GET /applications/myapp/serverGroups"])
+	require.Equal(t, 2, counts["GET /applications/myapp/loadBalancers"])
+	require.Equal(t, 2, counts["GET /search"])
+	require.Equal(t, 3, counts["GET /managed/resources/export/aws/test/cluster/myapp"])
+}
+
+func TestExportConcurrency(t *testing.T) {
+	ts := mdtest.NewServer(t, "../test-files/export")
+
+	expected, err := ioutil.ReadFile("../test-files/export/spinnaker.yml.expected")
+	require.NoError(t, err)
+
+	for _, concurrency := range []int{1, 2, 0} {
+		tdir, err := ioutil.TempDir("", "spinnaker-export")
+		require.NoError(t, err)
+		defer os.RemoveAll(tdir)
+
+		opts := NewCommandOptions()
+		opts.BaseURL = ts.URL
+		opts.ConfigDir = tdir
+		opts.ConfigFile = "spinnaker.yml"
+
+		var mu sync.Mutex
+		inFlight, maxInFlight := 0, 0
+		// rank is the position of each resource in the sorted resources
+		rank := map[mdlib.ExportableResource]int{}
+		_, err = Export(
+			opts,
+			"myapp",
+			AssumeEnvName("testing"),
+			ExportAll(true),
+			ExportConcurrency(concurrency),
+			CustomResourceScanner(func(appData *mdlib.ApplicationResources) []*mdlib.ExportableResource {
+				exportable := mdlib.ExportableApplicationResources(appData)
+				sorted := append([]*mdlib.ExportableResource{}, exportable...)
+				sort.Sort(mdlib.ResourceSorter(sorted))
+				for i, resource := range sorted {
+					rank[*resource] = i
+				}
+				return exportable
+			}),
+			CustomResourceExporterContext(func(ctx context.Context, cli *mdlib.Client, resource *mdlib.ExportableResource) ([]byte, error) {
+				mu.Lock()
+				inFlight++
+				if inFlight > maxInFlight {
+					maxInFlight = inFlight
+				}
+				mu.Unlock()
+				defer func() {
+					mu.Lock()
+					inFlight--
+					mu.Unlock()
+				}()
+				// finish in the reverse order of the sorted resources
+				time.Sleep(time.Duration(len(rank)-rank[*resource]) * 10 * time.Millisecond)
+				return exportResource(ctx, cli, resource)
+			}),
+		)
+		require.NoError(t, err)
+		require.Greater(t, len(rank), 2)
+
+		switch concurrency {
+		case 1:
+			require.Equal(t, 1, maxInFlight)
+		case 2:
+			require.Equal(t, 2, maxInFlight)
+		default:
+			require.Greater(t, maxInFlight, 2)
+		}
+
+		// the delivery config is the same no matter the order the exports finished
+		got, err := ioutil.ReadFile(filepath.Join(opts.ConfigDir, opts.ConfigFile))
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(got), "concurrency %d", concurrency)
+	}
+}
+
+func TestExportOwnership(t *testing.T) {
+	ts := mdtest.NewServer(t, "../test-files/export")
+
+	tdir, err := ioutil.TempDir("", "spinnaker-export")
+	require.NoError(t, err)
+	defer os.RemoveAll(tdir)
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = tdir
+	opts.ConfigFile = "spinnaker.yml"
+
+	ownership := mdlib.DefaultOwnershipPolicy("myapp")
+	ownership.Deny = []string{"myapp-rds"}
+	_, err = Export(
+		opts,
+		"myapp",
+		AssumeEnvName("testing"),
+		ExportAll(true),
+		Ownership(ownership),
+	)
+	require.NoError(t, err)
+
+	counts := ts.RequestCounts()
+	require.Equal(t, 1, counts["GET /managed/resources/export/aws/test/security-group/myapp"])
+	require.Equal(t, 0, counts["GET /managed/resources/export/aws/dbs/security-group/myapp-rds"])
+}
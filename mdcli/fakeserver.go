@@ -0,0 +1,46 @@
+package mdcli
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"golang.org/x/xerrors"
+)
+
+// FakeServer is a command line interface to run an in-memory fake of the Managed
+// Delivery API on addr, for local development without Spinnaker.  The live state
+// is seeded from liveFile when set, which uses the delivery config format.  The
+// server runs until the command Context is canceled.
+func FakeServer(opts *CommandOptions, addr, liveFile string, fakeOpts ...mdfake.Option) error {
+	md := mdfake.New(fakeOpts...)
+	if liveFile != "" {
+		content, err := ioutil.ReadFile(liveFile)
+		if err != nil {
+			return xerrors.Errorf("failed to read live state: %w", err)
+		}
+		err = md.SeedLive(content)
+		if err != nil {
+			return xerrors.Errorf("failed to load live state from %s: %w", liveFile, err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{Handler: md}
+	go func() {
+		<-opts.ctx().Done()
+		srv.Shutdown(context.Background())
+	}()
+
+	opts.Logger.Noticef("Fake Managed Delivery API listening on http://%s", listener.Addr())
+	err = srv.Serve(listener)
+	if err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package mdcli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeCommandOptions starts a server for handler, usually an mdfake.Server, and returns
+// CommandOptions to reach it using the delivery config from test-files/diff.
+func newFakeCommandOptions(t *testing.T, handler http.Handler) *CommandOptions {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/diff"
+	opts.ConfigFile = "spinnaker.yml"
+	return opts
+}
@@ -0,0 +1,125 @@
+package mdcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mgutz/ansi"
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+)
+
+// HistoryOptions allows for optional flags to the History command.
+type HistoryOptions struct {
+	// Since will only show events after the given time when set
+	Since time.Time
+	// Limit is the maximum number of events to request, the Spinnaker default
+	// is used when 0
+	Limit int
+	// JSON will print the events as JSON instead of a timeline
+	JSON bool
+}
+
+// History is a command line interface to display the management event history of a
+// resource from the local delivery config, resource can be the resource id or name.
+// Events are printed oldest first.
+func History(opts *CommandOptions, resource string, historyOpts HistoryOptions) error {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return err
+	}
+
+	resourceID, err := mdProcessor.ResourceID(resource)
+	if err != nil {
+		return err
+	}
+
+	cli := opts.newClient()
+
+	events, err := mdlib.GetResourceEventsContext(opts.ctx(), cli, resourceID, historyOpts.Limit)
+	if err != nil {
+		return err
+	}
+
+	// events are returned newest first
+	timeline := []mdlib.ResourceEvent{}
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Timestamp.Before(historyOpts.Since) {
+			continue
+		}
+		timeline = append(timeline, events[i])
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Timestamp.Before(timeline[j].Timestamp)
+	})
+
+	if historyOpts.JSON {
+		content, err := json.MarshalIndent(timeline, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.Stdout, "%s\n", content)
+		return nil
+	}
+
+	for _, event := range timeline {
+		fmt.Fprintf(opts.Stdout, "%s %s\n", event.Timestamp.Local().Format(time.RFC3339), eventColor(event.Type))
+		for _, detail := range eventDetails(event) {
+			fmt.Fprintf(opts.Stdout, "    %s\n", detail)
+		}
+	}
+	return nil
+}
+
+// eventDetails returns the lines describing the event for the timeline.
+func eventDetails(event mdlib.ResourceEvent) []string {
+	details := []string{}
+	if event.Message != "" {
+		details = append(details, event.Message)
+	}
+	if event.Reason != "" && event.Reason != event.Message {
+		details = append(details, "reason: "+event.Reason)
+	}
+	for _, task := range event.Tasks {
+		details = append(details, fmt.Sprintf("task %s: %s", task.ID, task.Name))
+	}
+	fields := []string{}
+	for field := range event.Delta {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		details = append(details, fmt.Sprintf("%s: %v", field, event.Delta[field]))
+	}
+	return details
+}
+
+// eventColor returns the event type colored by severity.
+func eventColor(eventType string) string {
+	color := "default"
+	switch {
+	case strings.Contains(eventType, "Error"), strings.Contains(eventType, "Failed"), strings.Contains(eventType, "Unhappy"):
+		color = "red"
+	case strings.Contains(eventType, "Valid"), strings.Contains(eventType, "Resolved"):
+		color = "green"
+	case strings.Contains(eventType, "Delta"), strings.Contains(eventType, "Launched"):
+		color = "yellow"
+	}
+	return ansi.Color(eventType, color)
+}
+
+// ParseSince parses the value for HistoryOptions.Since, which is either a duration
+// before now, like `24h`, or an RFC3339 timestamp.
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(since); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, since)
+	if err != nil {
+		return time.Time{}, xerrors.Errorf("expected a duration like 24h or an RFC3339 timestamp, got %q", since)
+	}
+	return t, nil
+}
@@ -0,0 +1,63 @@
+package mdcli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	md := mdfake.New(mdfake.WithActuation(0))
+	opts := newFakeCommandOptions(t, md)
+
+	exitCode, err := PublishAndWait(opts, false, mdlib.ConvergenceOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      5 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	history := func(historyOpts HistoryOptions) []mdlib.ResourceEvent {
+		stdout, err := ioutil.TempFile("", "history")
+		require.NoError(t, err)
+		defer os.Remove(stdout.Name())
+		defer stdout.Close()
+		opts.Stdout = stdout
+
+		historyOpts.JSON = true
+		require.NoError(t, History(opts, "ec2:cluster:test:myapp", historyOpts))
+		content, err := ioutil.ReadFile(stdout.Name())
+		require.NoError(t, err)
+		events := []mdlib.ResourceEvent{}
+		require.NoError(t, json.Unmarshal(content, &events))
+		return events
+	}
+
+	types := []string{}
+	for _, event := range history(HistoryOptions{}) {
+		types = append(types, event.Type)
+	}
+	require.Equal(t, []string{"ResourceDeltaDetected", "ResourceActuationLaunched", "ResourceDeltaResolved"}, types)
+
+	require.Empty(t, history(HistoryOptions{Since: time.Now().Add(time.Hour)}))
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	since, err := ParseSince("24h", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), since)
+
+	since, err = ParseSince("2019-12-31T12:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2019, 12, 31, 12, 0, 0, 0, time.UTC), since)
+
+	_, err = ParseSince("yesterday", now)
+	require.Error(t, err)
+}
@@ -1,10 +1,12 @@
 package mdcli
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"os"
 
+	"github.com/mattn/go-isatty"
 	mdlib "github.com/spinnaker/md-lib-go"
 )
 
@@ -13,24 +15,74 @@ type CommandOptions struct {
 	ConfigDir  string
 	ConfigFile string
 	BaseURL    string
+	// HTTPClient is used to send requests when set, otherwise the mdlib.Client
+	// default is used so TLS and proxy ClientOpts can be applied.
 	HTTPClient func(*http.Request) (*http.Response, error)
-	Logger     mdlib.Logger
-	Stdout     FdWriter
-	Stderr     io.Writer
-	Stdin      FdReader
+	// ClientOpts are additional options applied to the mdlib.Client used
+	// by the command.
+	ClientOpts []mdlib.ClientOpt
+	// ProcessorOpts are additional options applied to the
+	// mdlib.DeliveryConfigProcessor used by the command.
+	ProcessorOpts []mdlib.ProcessorOption
+	Logger        mdlib.Logger
+	Stdout        FdWriter
+	Stderr        io.Writer
+	Stdin         FdReader
+	// Context is used for all Spinnaker API requests made by the command,
+	// canceling it will abort any in-flight requests.
+	Context context.Context
 }
 
 // NewCommandOptions creates a new CommandOptions struct with a default logger and stdio
 func NewCommandOptions() *CommandOptions {
 	return &CommandOptions{
-		HTTPClient: http.DefaultClient.Do,
-		Logger:     mdlib.NewDefaultLogger(),
-		Stdout:     os.Stdout,
-		Stderr:     os.Stderr,
-		Stdin:      os.Stdin,
+		Context: context.Background(),
+		Logger:  mdlib.NewDefaultLogger(),
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+		Stdin:   os.Stdin,
 	}
 }
 
+// ctx returns the Context for the command, defaulting to context.Background
+// when not set.
+func (o *CommandOptions) ctx() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// interactive returns true when the user can be prompted, which requires Stdin
+// to be a terminal.
+func (o *CommandOptions) interactive() bool {
+	return o.Stdin != nil && isatty.IsTerminal(o.Stdin.Fd())
+}
+
+// newClient creates the mdlib.Client used to make Spinnaker API requests for
+// the command.
+func (o *CommandOptions) newClient() *mdlib.Client {
+	clientOpts := []mdlib.ClientOpt{
+		mdlib.WithBaseURL(o.BaseURL),
+	}
+	if o.HTTPClient != nil {
+		clientOpts = append(clientOpts, mdlib.WithHTTPClient(o.HTTPClient))
+	}
+	return mdlib.NewClient(append(clientOpts, o.ClientOpts...)...)
+}
+
+// newProcessor creates the mdlib.DeliveryConfigProcessor for the delivery
+// config file, extra options are applied before ProcessorOpts.
+func (o *CommandOptions) newProcessor(extra ...mdlib.ProcessorOption) *mdlib.DeliveryConfigProcessor {
+	processorOpts := []mdlib.ProcessorOption{
+		mdlib.WithDirectory(o.ConfigDir),
+		mdlib.WithFile(o.ConfigFile),
+		mdlib.WithLogger(o.Logger),
+	}
+	processorOpts = append(processorOpts, extra...)
+	return mdlib.NewDeliveryConfigProcessor(append(processorOpts, o.ProcessorOpts...)...)
+}
+
 // FdWriter represents an io.Writer with a Fd property. (*os.File implements this)
 type FdWriter interface {
 	io.Writer
@@ -24,15 +24,12 @@ func resumePause(opts *CommandOptions, appName string, pause bool) error {
 		return err
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
 
 	if pause {
-		err = mdlib.PauseManagement(cli, appName)
+		err = mdlib.PauseManagementContext(opts.ctx(), cli, appName)
 	} else {
-		err = mdlib.ResumeManagement(cli, appName)
+		err = mdlib.ResumeManagementContext(opts.ctx(), cli, appName)
 	}
 	if err != nil {
 		return err
@@ -41,3 +38,88 @@ func resumePause(opts *CommandOptions, appName string, pause bool) error {
 	opts.Logger.Noticef("OK")
 	return nil
 }
+
+// PauseResource is a command line interface to pause the management of a single resource
+// from the delivery config, resource can be the resource id or name.
+func PauseResource(opts *CommandOptions, resource string) error {
+	return resumePauseResource(opts, resource, true)
+}
+
+// ResumeResource is a command line interface to resume the paused management of a single
+// resource from the delivery config, resource can be the resource id or name.
+func ResumeResource(opts *CommandOptions, resource string) error {
+	return resumePauseResource(opts, resource, false)
+}
+
+func resumePauseResource(opts *CommandOptions, resource string, pause bool) error {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return err
+	}
+
+	resourceID, err := mdProcessor.ResourceID(resource)
+	if err != nil {
+		return err
+	}
+
+	cli := opts.newClient()
+
+	if pause {
+		err = mdlib.PauseResourceContext(opts.ctx(), cli, resourceID)
+	} else {
+		err = mdlib.ResumeResourceContext(opts.ctx(), cli, resourceID)
+	}
+	if err != nil {
+		return err
+	}
+
+	opts.Logger.Noticef("OK")
+	return nil
+}
+
+// PauseEnvironment is a command line interface to pause the management of all the
+// resources in an environment from the delivery config.
+func PauseEnvironment(opts *CommandOptions, envName string) error {
+	return resumePauseEnvironment(opts, envName, true)
+}
+
+// ResumeEnvironment is a command line interface to resume the paused management of all
+// the resources in an environment from the delivery config.
+func ResumeEnvironment(opts *CommandOptions, envName string) error {
+	return resumePauseEnvironment(opts, envName, false)
+}
+
+func resumePauseEnvironment(opts *CommandOptions, envName string, pause bool) error {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return err
+	}
+
+	cli := opts.newClient()
+
+	if pause {
+		err = mdProcessor.PauseEnvironmentContext(opts.ctx(), cli, envName)
+	} else {
+		err = mdProcessor.ResumeEnvironmentContext(opts.ctx(), cli, envName)
+	}
+	if err != nil {
+		return err
+	}
+
+	opts.Logger.Noticef("OK")
+	return nil
+}
+
+// loadProcessor loads the delivery config, returning an error if it does not exist.
+func loadProcessor(opts *CommandOptions) (*mdlib.DeliveryConfigProcessor, error) {
+	configPath := filepath.Join(opts.ConfigDir, opts.ConfigFile)
+	if _, err := os.Stat(configPath); err != nil {
+		return nil, err
+	}
+
+	mdProcessor := opts.newProcessor()
+	if err := mdProcessor.Load(); err != nil {
+		return nil, err
+	}
+	return mdProcessor, nil
+}
@@ -0,0 +1,72 @@
+package mdcli
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseResource(t *testing.T) {
+	md := mdfake.New()
+	opts := newFakeCommandOptions(t, md)
+
+	require.NoError(t, PauseResource(opts, "ec2:cluster:test:myapp"))
+	require.True(t, md.ResourcePaused("myapp", "ec2:cluster:test:myapp"))
+	require.False(t, md.ResourcePaused("myapp", "titus:cluster:titustest:myapp"))
+	require.NoError(t, ResumeResource(opts, "ec2:cluster:test:myapp"))
+	require.False(t, md.ResourcePaused("myapp", "ec2:cluster:test:myapp"))
+
+	// the name is ambiguous, it matches all the resources in the config
+	require.Error(t, PauseResource(opts, "myapp"))
+	require.Error(t, PauseResource(opts, "missing"))
+}
+
+func TestPauseEnvironment(t *testing.T) {
+	md := mdfake.New()
+	opts := newFakeCommandOptions(t, md)
+
+	ids := []string{
+		"ec2:cluster:test:myapp",
+		"titus:cluster:titustest:myapp",
+		"ec2:security-group:test:myapp",
+	}
+	require.NoError(t, PauseEnvironment(opts, "testing"))
+	for _, id := range ids {
+		require.True(t, md.ResourcePaused("myapp", id), id)
+	}
+	require.NoError(t, ResumeEnvironment(opts, "testing"))
+	for _, id := range ids {
+		require.False(t, md.ResourcePaused("myapp", id), id)
+	}
+
+	require.Error(t, PauseEnvironment(opts, "production"))
+}
+
+func TestPauseEnvironmentPartialFailure(t *testing.T) {
+	md := mdfake.New()
+	opts := newFakeCommandOptions(t, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if strings.Contains(r.URL.Path, "titus") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			md.ServeHTTP(w, r)
+		},
+	))
+
+	// the resources after the failure are still paused
+	err := PauseEnvironment(opts, "testing")
+	partial := mdlib.ErrorPartialUpdate{}
+	require.True(t, errors.As(err, &partial))
+	require.Equal(t, []string{"titus:cluster:titustest:myapp"}, partial.Failed)
+	require.Equal(t, []string{"ec2:cluster:test:myapp", "ec2:security-group:test:myapp"}, partial.Succeeded)
+	require.True(t, errors.Is(err, mdlib.ErrForbidden))
+	require.Contains(t, err.Error(), "failed to update 1 of 3 resources")
+	require.True(t, md.ResourcePaused("myapp", "ec2:cluster:test:myapp"))
+	require.True(t, md.ResourcePaused("myapp", "ec2:security-group:test:myapp"))
+}
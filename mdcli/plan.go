@@ -6,7 +6,6 @@ import (
 	"path/filepath"
 
 	"github.com/mgutz/ansi"
-	mdlib "github.com/spinnaker/md-lib-go"
 )
 
 // Plan returns actuation plan for a local delivery config
@@ -16,18 +15,11 @@ func Plan(opts *CommandOptions) (int, error) {
 		return 1, err
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
 
-	mdProcessor := mdlib.NewDeliveryConfigProcessor(
-		mdlib.WithDirectory(opts.ConfigDir),
-		mdlib.WithFile(opts.ConfigFile),
-		mdlib.WithLogger(opts.Logger),
-	)
+	mdProcessor := opts.newProcessor()
 
-	plan, err := mdProcessor.Plan(cli)
+	plan, err := mdProcessor.PlanContext(opts.ctx(), cli)
 	if err != nil {
 		return 1, err
 	}
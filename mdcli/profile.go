@@ -0,0 +1,25 @@
+package mdcli
+
+import (
+	mdlib "github.com/spinnaker/md-lib-go"
+)
+
+// NewCommandOptionsFromProfile creates a new CommandOptions struct with a default
+// logger and stdio configured to reach the Spinnaker installation from profile.
+func NewCommandOptionsFromProfile(profile *mdlib.Profile) *CommandOptions {
+	opts := NewCommandOptions()
+	opts.ConfigDir = mdlib.DefaultDeliveryConfigDirName
+	opts.ConfigFile = mdlib.DefaultDeliveryConfigFileName
+	opts.BaseURL = profile.BaseURL
+	if profile.HTTPClient != nil {
+		opts.HTTPClient = profile.HTTPClient
+	}
+	opts.ClientOpts = append(opts.ClientOpts, profile.ClientOpts...)
+	if profile.ConfigDir != "" {
+		opts.ConfigDir = profile.ConfigDir
+	}
+	if profile.ConfigFile != "" {
+		opts.ConfigFile = profile.ConfigFile
+	}
+	return opts
+}
@@ -0,0 +1,52 @@
+package mdcli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCommandOptionsFromProfile(t *testing.T) {
+	opts := NewCommandOptionsFromProfile(&mdlib.Profile{Name: "test", BaseURL: "https://gate.test.example.com"})
+	require.Equal(t, "https://gate.test.example.com", opts.BaseURL)
+	require.Equal(t, mdlib.DefaultDeliveryConfigDirName, opts.ConfigDir)
+	require.Equal(t, mdlib.DefaultDeliveryConfigFileName, opts.ConfigFile)
+	require.Nil(t, opts.HTTPClient)
+
+	requests := 0
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "Bearer s3cr3t", r.Header.Get("Authorization"))
+				requests++
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[]`))
+			},
+		),
+	)
+	defer ts.Close()
+
+	opts = NewCommandOptionsFromProfile(&mdlib.Profile{
+		Name:       "prod",
+		BaseURL:    ts.URL,
+		HTTPClient: http.DefaultClient.Do,
+		ClientOpts: []mdlib.ClientOpt{mdlib.WithBearerToken("s3cr3t")},
+		ConfigDir:  "deploy",
+		ConfigFile: "prod.yml",
+	})
+	require.Equal(t, "deploy", opts.ConfigDir)
+	require.Equal(t, "prod.yml", opts.ConfigFile)
+	require.NoError(t, mdlib.GetServerGroups(opts.newClient(), "myapp", &[]mdlib.ServerGroup{}))
+	require.Equal(t, 1, requests)
+
+	// transport options work with the default http client
+	opts = NewCommandOptionsFromProfile(&mdlib.Profile{
+		BaseURL:    ts.URL,
+		ClientOpts: []mdlib.ClientOpt{mdlib.WithBearerToken("s3cr3t"), mdlib.WithTransport(mdlib.Proxy(""))},
+	})
+	require.NoError(t, mdlib.GetServerGroups(opts.newClient(), "myapp", &[]mdlib.ServerGroup{}))
+	require.Equal(t, 2, requests)
+}
@@ -3,14 +3,20 @@ package mdcli
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	mdlib "github.com/spinnaker/md-lib-go"
 )
 
 // PublishError is the format of an error message upon publishing a delivery
 // config.
+//
+// Deprecated: use mdlib.ErrorUnexpectedResponse.Message to get the nested
+// error message.
 type PublishError struct {
 	Timestamp int64
 	Status    int64
@@ -47,35 +53,103 @@ func (body *PublishErrorBody) UnmarshalJSON(b []byte) error {
 // Publish is a command line interface for publishing a local delivery config
 // to be managed by Spinnaker.
 func Publish(opts *CommandOptions, force bool) (int, error) {
+	return publish(opts, force, nil)
+}
+
+// PublishAndWait is like Publish but after publishing it waits for every resource in
+// the delivery config to converge, printing progress as resources converge.  The exit
+// code is non-zero if any resource fails or does not converge before the timeout.
+// Unless waitOpts.Changed is set, the resources with a diff before publishing are
+// the changed resources.
+func PublishAndWait(opts *CommandOptions, force bool, waitOpts mdlib.ConvergenceOptions) (int, error) {
+	return publish(opts, force, &waitOpts)
+}
+
+func publish(opts *CommandOptions, force bool, waitOpts *mdlib.ConvergenceOptions) (int, error) {
 	configPath := filepath.Join(opts.ConfigDir, opts.ConfigFile)
 	if _, err := os.Stat(configPath); err != nil {
 		return 1, err
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
+
+	mdProcessor := opts.newProcessor()
 
-	mdProcessor := mdlib.NewDeliveryConfigProcessor(
-		mdlib.WithDirectory(opts.ConfigDir),
-		mdlib.WithFile(opts.ConfigFile),
-		mdlib.WithLogger(opts.Logger),
-	)
+	if waitOpts != nil && waitOpts.Since.IsZero() {
+		waitOpts.Since = time.Now()
+	}
+	if waitOpts != nil && len(waitOpts.Changed) == 0 {
+		// the statuses of the changed resources are stale until spinnaker checks
+		// them again, so find them before publishing
+		diffs, err := mdProcessor.DiffContext(opts.ctx(), cli)
+		if err != nil {
+			opts.Logger.Noticef("Unable to find the resources changed by the publish, statuses may be stale: %s", err)
+		}
+		for _, diff := range diffs {
+			if diff.Status != "NO_DIFF" {
+				waitOpts.Changed = append(waitOpts.Changed, diff.ResourceID)
+			}
+		}
+	}
 
-	err := mdProcessor.Publish(cli, force)
+	err := mdProcessor.PublishContext(opts.ctx(), cli, force)
 	if err != nil {
 		var e mdlib.ErrorUnexpectedResponse
 		if errors.As(err, &e) {
-			pe := &PublishError{}
-			e.Parse(pe)
 			opts.Logger.Errorf("Failed to publish delivery config.  Spinnaker responded with:")
-			opts.Logger.Errorf(pe.Body.Message)
+			msg := e.Message()
+			if msg == "" {
+				msg = string(e.Content)
+			}
+			opts.Logger.Errorf("%s", msg)
 			return 1, nil
 		}
 		return 1, err
 	}
 
 	opts.Logger.Noticef("OK")
+	if waitOpts == nil {
+		return 0, nil
+	}
+	return waitForConvergence(opts, cli, mdProcessor, *waitOpts)
+}
+
+func waitForConvergence(opts *CommandOptions, cli *mdlib.Client, mdProcessor *mdlib.DeliveryConfigProcessor, waitOpts mdlib.ConvergenceOptions) (int, error) {
+	if len(waitOpts.ResourceIDs) == 0 {
+		waitOpts.ResourceIDs = mdProcessor.ResourceIDs()
+	}
+	lastProgress := ""
+	waitOpts.Progress = func(pending []mdlib.ResourceSummary) {
+		statuses := []string{}
+		for _, resource := range pending {
+			statuses = append(statuses, fmt.Sprintf("%s %s", resource.Status, resource.ID))
+		}
+		// only report when the state changes to avoid flooding the output
+		progress := strings.Join(statuses, ", ")
+		if progress != lastProgress {
+			opts.Logger.Noticef("Waiting for %d of %d resources to converge: %s", len(pending), len(waitOpts.ResourceIDs), progress)
+			lastProgress = progress
+		}
+	}
+
+	_, err := mdlib.WaitForConvergence(opts.ctx(), cli, mdProcessor.Application(), waitOpts)
+	if err != nil {
+		var e mdlib.ErrorNotConverged
+		if errors.As(err, &e) {
+			for _, resource := range e.Failed {
+				opts.Logger.Errorf("Resource %s failed to converge: %s", resource.ID, resource.Status)
+			}
+			for _, resource := range e.Pending {
+				opts.Logger.Errorf("Resource %s did not converge: %s", resource.ID, resource.Status)
+			}
+			if e.Err != nil {
+				opts.Logger.Errorf("Gave up waiting for resources to converge: %s", e.Err)
+			}
+			return 1, nil
+		}
+		return 1, err
+	}
+
+	opts.Logger.Noticef("All resources converged")
 	return 0, nil
 }
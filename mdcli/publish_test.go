@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,3 +38,151 @@ func TestPublish(t *testing.T) {
 		"POST /managed/delivery-configs?force=false": 1,
 	}, requests)
 }
+
+func TestPublishNotRetried(t *testing.T) {
+	requests := map[string]int{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests[fmt.Sprintf("%s %s", r.Method, r.URL.String())]++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		),
+	)
+	defer ts.Close()
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/publish"
+	opts.ConfigFile = "spinnaker.yml"
+	opts.ClientOpts = append(opts.ClientOpts, mdlib.WithRetryPolicy(mdlib.RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+	}))
+
+	exitCode, _ := Publish(opts, false)
+	require.Equal(t, 1, exitCode)
+
+	// publishing is not side-effect free so it must only be attempted once
+	require.Equal(t, map[string]int{
+		"POST /managed/delivery-configs?force=false": 1,
+	}, requests)
+}
+
+type captureLogger struct {
+	lines []string
+}
+
+func (l *captureLogger) Printf(format string, v ...any) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func (l *captureLogger) Noticef(format string, v ...any) { l.Printf(format, v...) }
+
+func (l *captureLogger) Errorf(format string, v ...any) { l.Printf(format, v...) }
+
+func TestPublishMiddleware(t *testing.T) {
+	headers := http.Header{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+				w.WriteHeader(http.StatusOK)
+			},
+		),
+	)
+	defer ts.Close()
+
+	debugLog := &captureLogger{}
+	order := []string{}
+	tracer := func(name string) mdlib.Middleware {
+		return func(next mdlib.Doer) mdlib.Doer {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next(req)
+			}
+		}
+	}
+
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/publish"
+	opts.ConfigFile = "spinnaker.yml"
+	opts.ClientOpts = append(opts.ClientOpts,
+		mdlib.WithBearerToken("s3cr3t"),
+		mdlib.WithMiddleware(
+			tracer("first"),
+			mdlib.UserAgentMiddleware("md-test"),
+			mdlib.CorrelationIDMiddleware(func() string { return "abc123" }),
+			tracer("second"),
+			mdlib.DebugMiddleware(debugLog),
+		),
+	)
+
+	_, err := Publish(opts, false)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"first", "second"}, order)
+	require.Equal(t, "md-test", headers.Get("User-Agent"))
+	require.Equal(t, "abc123", headers.Get(mdlib.DefaultCorrelationIDHeader))
+	require.Equal(t, "Bearer s3cr3t", headers.Get("Authorization"))
+
+	logged := strings.Join(debugLog.lines, "\n")
+	require.Contains(t, logged, "POST /managed/delivery-configs?force=false")
+	require.Contains(t, logged, "Authorization: REDACTED")
+	require.False(t, strings.Contains(logged, "s3cr3t"))
+}
+
+func TestPublishErrorMessage(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{
+					"timestamp": 1600000000000,
+					"status": 400,
+					"error": "Bad Request",
+					"message": "400 Bad Request",
+					"body": "{\"message\":\"Delivery config name myapp-manifest is already in use\",\"status\":400,\"error\":\"Bad Request\"}",
+					"url": "http://keel/delivery-configs"
+				}`)
+			},
+		),
+	)
+	defer ts.Close()
+
+	logger := &captureLogger{}
+	opts := NewCommandOptions()
+	opts.BaseURL = ts.URL
+	opts.ConfigDir = "../test-files/publish"
+	opts.ConfigFile = "spinnaker.yml"
+	opts.Logger = logger
+
+	exitCode, err := Publish(opts, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, exitCode)
+	require.Contains(t, logger.lines, "Delivery config name myapp-manifest is already in use")
+}
+
+func TestPublishAndWait(t *testing.T) {
+	md := mdfake.New(mdfake.WithActuation(20 * time.Millisecond))
+	opts := newFakeCommandOptions(t, md)
+
+	exitCode, err := PublishAndWait(opts, false, mdlib.ConvergenceOptions{
+		PollInterval: 5 * time.Millisecond,
+		Timeout:      5 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	// a paused resource will never converge
+	require.NoError(t, PauseResource(opts, "ec2:cluster:test:myapp"))
+	exitCode, err = PublishAndWait(opts, false, mdlib.ConvergenceOptions{
+		PollInterval: 5 * time.Millisecond,
+		Timeout:      5 * time.Second,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, exitCode)
+}
@@ -0,0 +1,63 @@
+package mdcli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	"gopkg.in/yaml.v3"
+)
+
+// Pull is a command line interface to merge changes made to the delivery config stored
+// in Spinnaker, like edits from the UI or publishes by teammates, into the local delivery
+// config.  Changes are merged relative to the last published delivery config, recorded
+// when mdlib.WithPublishedDirectory is in ProcessorOpts.  If any changes conflict the
+// conflicts are printed, the local delivery config is not modified and the exit code is 1.
+func Pull(opts *CommandOptions) (int, error) {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return 1, err
+	}
+
+	cli := opts.newClient()
+
+	conflicts, err := mdProcessor.PullContext(opts.ctx(), cli)
+	if err != nil {
+		return 1, err
+	}
+
+	if len(conflicts) == 0 {
+		opts.Logger.Noticef("OK")
+		return 0, nil
+	}
+
+	opts.Logger.Errorf("Found %d conflicts with the delivery config in Spinnaker, %s was not modified", len(conflicts), opts.ConfigFile)
+	for _, conflict := range conflicts {
+		fmt.Fprintf(opts.Stdout, "%s\n", ansi.Color(conflict.String(), "red+b"))
+		fmt.Fprintf(opts.Stdout, "  base:   %s\n", conflictValue(conflict.Base))
+		fmt.Fprintf(opts.Stdout, "  local:  %s\n", ansi.Color(conflictValue(conflict.Local), "green"))
+		fmt.Fprintf(opts.Stdout, "  remote: %s\n", ansi.Color(conflictValue(conflict.Remote), "yellow"))
+	}
+	return 1, nil
+}
+
+// conflictValue formats the conflicting value as YAML, multi-line values are indented
+// to line up with the conflict output.
+func conflictValue(node *yaml.Node) string {
+	if node == nil {
+		return "<missing>"
+	}
+	content, err := yaml.Marshal(node)
+	if err != nil {
+		return fmt.Sprintf("<%s>", err)
+	}
+	value := strings.TrimSuffix(string(content), "\n")
+	if !strings.Contains(value, "\n") {
+		return value
+	}
+	return "\n" + indent(value, "      ")
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
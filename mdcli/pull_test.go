@@ -0,0 +1,101 @@
+package mdcli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPull(t *testing.T) {
+	md := mdfake.New()
+	tmpDir, err := ioutil.TempDir("", "pull")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	original, err := ioutil.ReadFile("../test-files/diff/spinnaker.yml")
+	require.NoError(t, err)
+
+	// local is the delivery config we are editing, remote is edited by a teammate
+	newOpts := func(dir string) *CommandOptions {
+		opts := newFakeCommandOptions(t, md)
+		opts.ConfigDir = filepath.Join(tmpDir, dir)
+		opts.ProcessorOpts = []mdlib.ProcessorOption{
+			mdlib.WithPublishedDirectory(filepath.Join(tmpDir, dir+"-published")),
+		}
+		require.NoError(t, os.MkdirAll(opts.ConfigDir, 0o755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(opts.ConfigDir, opts.ConfigFile), original, 0o644))
+		return opts
+	}
+	local := newOpts("local")
+	remote := newOpts("remote")
+
+	edit := func(opts *CommandOptions, old, new string) {
+		path := filepath.Join(opts.ConfigDir, opts.ConfigFile)
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		require.Contains(t, string(content), old)
+		require.NoError(t, ioutil.WriteFile(path, []byte(strings.Replace(string(content), old, new, 1)), 0o644))
+	}
+	read := func(opts *CommandOptions) string {
+		content, err := ioutil.ReadFile(filepath.Join(opts.ConfigDir, opts.ConfigFile))
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	exitCode, err := Publish(local, false)
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	edit(remote, "maxServerGroups: 2", "maxServerGroups: 3")
+	exitCode, err = Publish(remote, false)
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	// independent changes are merged
+	edit(local, "- Default", "- OldestInstance")
+	exitCode, err = Pull(local)
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	content := read(local)
+	require.Contains(t, content, "maxServerGroups: 3")
+	require.Contains(t, content, "- OldestInstance")
+
+	// the pulled config is the base for the next pull, so pulling again
+	// does not conflict
+	exitCode, err = Pull(local)
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+	require.Equal(t, content, read(local))
+
+	// conflicting changes are reported and the local config is not modified
+	edit(remote, "strategy: red-black", "strategy: highlander")
+	exitCode, err = Publish(remote, false)
+	require.NoError(t, err)
+	require.Equal(t, 0, exitCode)
+
+	edit(local, "strategy: red-black", "strategy: rolling-push")
+	content = read(local)
+
+	stdout, err := ioutil.TempFile("", "pull")
+	require.NoError(t, err)
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+	local.Stdout = stdout
+
+	exitCode, err = Pull(local)
+	require.NoError(t, err)
+	require.Equal(t, 1, exitCode)
+	require.Equal(t, content, read(local))
+
+	output, err := ioutil.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	require.Contains(t, string(output), "/environments/testing/resources/ec2:cluster:test:myapp/spec/deployWith/strategy")
+	require.Contains(t, string(output), "rolling-push")
+	require.Contains(t, string(output), "highlander")
+}
@@ -0,0 +1,75 @@
+package mdcli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mgutz/ansi"
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+)
+
+// StatusOptions allows for optional flags to the Status command.
+type StatusOptions struct {
+	// JSON will print the application summary as JSON instead of text
+	JSON bool
+}
+
+// Status is a command line interface to display the management status of the
+// application from the local delivery config, including the environments and
+// the status of each resource known to Spinnaker.
+func Status(opts *CommandOptions, statusOpts StatusOptions) error {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return err
+	}
+	appName := mdProcessor.Application()
+	if appName == "" {
+		return xerrors.Errorf("application not found in %s", opts.ConfigFile)
+	}
+
+	cli := opts.newClient()
+
+	summary, err := mdlib.GetApplicationSummaryContext(opts.ctx(), cli, appName)
+	if err != nil {
+		return err
+	}
+
+	if statusOpts.JSON {
+		content, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(opts.Stdout, "%s\n", content)
+		return nil
+	}
+
+	state := ansi.Color("MANAGED", "green")
+	if summary.ApplicationPaused {
+		state = ansi.Color("PAUSED", "yellow")
+	}
+	fmt.Fprintf(opts.Stdout, "Application: %s %s\n", appName, state)
+	for _, env := range summary.Environments {
+		fmt.Fprintf(opts.Stdout, "%sEnvironment: %s%s\n", ansi.ColorCode("default+hb"), env.Name, ansi.Reset)
+		for _, id := range env.Resources {
+			status := mdlib.ResourceStatus("UNKNOWN")
+			if resource, ok := summary.Resource(id); ok {
+				status = resource.Status
+			}
+			fmt.Fprintf(opts.Stdout, "  %s %s\n", statusColor(status), id)
+		}
+	}
+	return nil
+}
+
+// statusColor returns the resource status colored by severity.
+func statusColor(status mdlib.ResourceStatus) string {
+	color := "yellow"
+	switch status {
+	case "HAPPY":
+		color = "green"
+	case "ERROR", "UNHAPPY":
+		color = "red"
+	}
+	return ansi.Color(string(status), color)
+}
@@ -0,0 +1,55 @@
+package mdcli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	md := mdfake.New()
+	live, err := ioutil.ReadFile("../test-files/diff/spinnaker.yml")
+	require.NoError(t, err)
+	require.NoError(t, md.SeedLive(live))
+	opts := newFakeCommandOptions(t, md)
+
+	stdout, err := ioutil.TempFile("", "status")
+	require.NoError(t, err)
+	defer os.Remove(stdout.Name())
+	defer stdout.Close()
+
+	opts.Stdout = stdout
+
+	// not managed yet
+	err = Status(opts, StatusOptions{})
+	require.ErrorIs(t, err, mdlib.ErrNotFound)
+
+	_, err = Publish(opts, false)
+	require.NoError(t, err)
+	require.NoError(t, PauseResource(opts, "ec2:cluster:test:myapp"))
+
+	err = Status(opts, StatusOptions{JSON: true})
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	summary := mdlib.ApplicationSummary{}
+	require.NoError(t, json.Unmarshal(content, &summary))
+	require.False(t, summary.ApplicationPaused)
+	require.Len(t, summary.Environments, 1)
+	require.Equal(t, "testing", summary.Environments[0].Name)
+	statuses := map[string]mdlib.ResourceStatus{}
+	for _, resource := range summary.Resources {
+		statuses[resource.ID] = resource.Status
+	}
+	require.Equal(t, map[string]mdlib.ResourceStatus{
+		"ec2:cluster:test:myapp":        "PAUSED",
+		"titus:cluster:titustest:myapp": "HAPPY",
+		"ec2:security-group:test:myapp": "HAPPY",
+	}, statuses)
+}
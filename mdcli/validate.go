@@ -3,8 +3,6 @@ package mdcli
 import (
 	"os"
 	"path/filepath"
-
-	mdlib "github.com/spinnaker/md-lib-go"
 )
 
 // Validate is a command line interface for validating a local delivery conifg.
@@ -14,18 +12,11 @@ func Validate(opts *CommandOptions) (int, error) {
 		return 1, err
 	}
 
-	cli := mdlib.NewClient(
-		mdlib.WithBaseURL(opts.BaseURL),
-		mdlib.WithHTTPClient(opts.HTTPClient),
-	)
+	cli := opts.newClient()
 
-	mdProcessor := mdlib.NewDeliveryConfigProcessor(
-		mdlib.WithDirectory(opts.ConfigDir),
-		mdlib.WithFile(opts.ConfigFile),
-		mdlib.WithLogger(opts.Logger),
-	)
+	mdProcessor := opts.newProcessor()
 
-	valErr, err := mdProcessor.Validate(cli)
+	valErr, err := mdProcessor.ValidateContext(opts.ctx(), cli)
 	if err != nil {
 		opts.Logger.Errorf("Could not validate the configuration: %s\n", err)
 		opts.Logger.Errorf("Exiting without failing\n")
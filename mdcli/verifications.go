@@ -0,0 +1,127 @@
+package mdcli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mgutz/ansi"
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+)
+
+// VerificationsOptions allows for optional flags to the Verifications command.
+type VerificationsOptions struct {
+	// Environment limits the verifications to a single environment when set
+	Environment string
+	// Version and Reference limit the verifications to an artifact version
+	// or artifact when set
+	Version   string
+	Reference string
+	// JSON will print the verifications as JSON instead of a report
+	JSON bool
+}
+
+// Verifications is a command line interface to report the verifications run for each
+// environment and artifact version of the application from the local delivery config.
+// The exit code is 0 only if verifications were found and all of them passed, so it can
+// be used to gate promotions in scripts.
+func Verifications(opts *CommandOptions, verificationsOpts VerificationsOptions) (int, error) {
+	mdProcessor, err := loadProcessor(opts)
+	if err != nil {
+		return 1, err
+	}
+	appName := mdProcessor.Application()
+	if appName == "" {
+		return 1, xerrors.Errorf("application not found in %s", opts.ConfigFile)
+	}
+	envNames, err := selectEnvironments(mdProcessor, verificationsOpts.Environment)
+	if err != nil {
+		return 1, err
+	}
+
+	cli := opts.newClient()
+
+	states, err := mdlib.EnvironmentVerificationsContext(opts.ctx(), cli, appName, envNames)
+	if err != nil {
+		return 1, err
+	}
+	verifications := []mdlib.VerificationState{}
+	for _, state := range states {
+		if verificationsOpts.Version != "" && state.ArtifactVersion != verificationsOpts.Version {
+			continue
+		}
+		if verificationsOpts.Reference != "" && state.ArtifactReference != verificationsOpts.Reference {
+			continue
+		}
+		verifications = append(verifications, state)
+	}
+
+	exit := 0
+	if len(verifications) == 0 {
+		exit = 1
+	}
+	for _, state := range verifications {
+		if !state.Status.Passed() {
+			exit = 1
+		}
+	}
+
+	if verificationsOpts.JSON {
+		content, err := json.MarshalIndent(verifications, "", "  ")
+		if err != nil {
+			return 1, err
+		}
+		fmt.Fprintf(opts.Stdout, "%s\n", content)
+		return exit, nil
+	}
+
+	if len(verifications) == 0 {
+		opts.Logger.Noticef("No verifications found")
+		return exit, nil
+	}
+
+	// group by environment and artifact version, in the order returned
+	heading := ""
+	for _, state := range verifications {
+		current := fmt.Sprintf("%s %s %s", state.EnvironmentName, state.ArtifactReference, state.ArtifactVersion)
+		if current != heading {
+			heading = current
+			fmt.Fprintf(opts.Stdout, "%s\n", ansi.Color(heading, "default+b"))
+		}
+		details := []string{verificationColor(state.Status), state.ID}
+		if state.Link != "" {
+			details = append(details, state.Link)
+		}
+		fmt.Fprintf(opts.Stdout, "    %s\n", strings.Join(details, " "))
+	}
+	return exit, nil
+}
+
+// verificationColor returns the status colored by outcome, padded to line up the
+// report.
+func verificationColor(status mdlib.VerificationStatus) string {
+	color := "yellow"
+	switch {
+	case status.Passed():
+		color = "green"
+	case status.Failed():
+		color = "red"
+	}
+	return ansi.Color(fmt.Sprintf("%-13s", status), color)
+}
+
+// selectEnvironments returns envName if set and it is in the delivery config, otherwise
+// all the environment names.
+func selectEnvironments(mdProcessor *mdlib.DeliveryConfigProcessor, envName string) ([]string, error) {
+	envNames := mdProcessor.EnvironmentNames()
+	if envName == "" {
+		return envNames, nil
+	}
+	for _, name := range envNames {
+		if name == envName {
+			return []string{envName}, nil
+		}
+	}
+	return nil, xerrors.Errorf("environment %q not found in delivery config, expected one of: %s", envName, strings.Join(envNames, ", "))
+}
@@ -0,0 +1,73 @@
+package mdcli
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/md-lib-go/mdfake"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifications(t *testing.T) {
+	md := mdfake.New()
+	md.AddVerificationState("myapp", mdlib.VerificationState{
+		EnvironmentName:   "testing",
+		ArtifactReference: "myapp",
+		ArtifactVersion:   "myapp-1.0.0",
+		ID:                "smoke-test",
+		Type:              "test-container",
+		Status:            mdlib.VerificationPass,
+		Link:              "https://titus.example.com/jobs/1",
+	})
+	md.AddVerificationState("myapp", mdlib.VerificationState{
+		EnvironmentName:   "testing",
+		ArtifactReference: "myapp",
+		ArtifactVersion:   "myapp-1.0.1",
+		ID:                "smoke-test",
+		Type:              "test-container",
+		Status:            mdlib.VerificationFail,
+	})
+	opts := newFakeCommandOptions(t, md)
+
+	verifications := func(verificationsOpts VerificationsOptions) (int, []mdlib.VerificationState) {
+		stdout, err := ioutil.TempFile("", "verifications")
+		require.NoError(t, err)
+		defer os.Remove(stdout.Name())
+		defer stdout.Close()
+		opts.Stdout = stdout
+
+		verificationsOpts.JSON = true
+		exitCode, err := Verifications(opts, verificationsOpts)
+		require.NoError(t, err)
+
+		content, err := ioutil.ReadFile(stdout.Name())
+		require.NoError(t, err)
+		states := []mdlib.VerificationState{}
+		require.NoError(t, json.Unmarshal(content, &states))
+		return exitCode, states
+	}
+
+	exitCode, states := verifications(VerificationsOptions{})
+	require.Equal(t, 1, exitCode)
+	require.Len(t, states, 2)
+
+	exitCode, states = verifications(VerificationsOptions{Environment: "testing", Version: "myapp-1.0.0"})
+	require.Equal(t, 0, exitCode)
+	require.Len(t, states, 1)
+	require.Equal(t, "https://titus.example.com/jobs/1", states[0].Link)
+
+	exitCode, states = verifications(VerificationsOptions{Version: "myapp-1.0.1"})
+	require.Equal(t, 1, exitCode)
+	require.Equal(t, mdlib.VerificationFail, states[0].Status)
+
+	// nothing has been verified yet
+	exitCode, states = verifications(VerificationsOptions{Version: "myapp-1.0.2"})
+	require.Equal(t, 1, exitCode)
+	require.Empty(t, states)
+
+	_, err := Verifications(opts, VerificationsOptions{Environment: "production"})
+	require.Error(t, err)
+}
@@ -0,0 +1,820 @@
+// Package mdfake provides a stateful in-memory fake of the Spinnaker Managed
+// Delivery (Keel) endpoints used by mdlib, for local development and end to end
+// tests without a Spinnaker installation.
+//
+// Published delivery configs are stored in memory and can be deleted, pause state
+// is tracked per application, and diffs and actuation plans are calculated against
+// a "live" state seeded with SeedLive.
+package mdfake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	deliveryConfigsPath = "/managed/delivery-configs"
+	applicationPath     = "/managed/application/"
+	resourcesPath       = "/managed/resources/"
+)
+
+// ManagedDelivery is an http.Handler emulating the Managed Delivery endpoints.  It
+// is safe for concurrent use.
+type ManagedDelivery struct {
+	mu      sync.Mutex
+	configs map[string]*deliveryConfig
+	live    map[string]*resource
+	paused  map[string]bool
+	// pausedResources are the ids of individually paused resources
+	pausedResources map[string]bool
+	// actuating is when each resource being actuated will converge
+	actuating      map[string]time.Time
+	pins           map[artifactKey]string
+	vetoes         map[artifactVersionKey]bool
+	constraints    map[string][]*mdlib.ConstraintState
+	verifications  map[string][]mdlib.VerificationState
+	events         map[string][]mdlib.ResourceEvent
+	actuate        bool
+	actuationDelay time.Duration
+}
+
+// Option is used to configure a ManagedDelivery via New.
+type Option func(*ManagedDelivery)
+
+// WithActuation is an Option to converge the live state to the published delivery
+// configs.  Managed resources that differ from the live state are reported as
+// ACTUATING for delay, then the live state is updated to match.  By default the
+// live state only changes via SeedLive.
+func WithActuation(delay time.Duration) Option {
+	return func(m *ManagedDelivery) {
+		m.actuate = true
+		m.actuationDelay = delay
+	}
+}
+
+// New returns a ManagedDelivery with no delivery configs and an empty live state.
+func New(opts ...Option) *ManagedDelivery {
+	m := &ManagedDelivery{
+		configs:         map[string]*deliveryConfig{},
+		live:            map[string]*resource{},
+		paused:          map[string]bool{},
+		pausedResources: map[string]bool{},
+		actuating:       map[string]time.Time{},
+		pins:            map[artifactKey]string{},
+		vetoes:          map[artifactVersionKey]bool{},
+		constraints:     map[string][]*mdlib.ConstraintState{},
+		verifications:   map[string][]mdlib.VerificationState{},
+		events:          map[string][]mdlib.ResourceEvent{},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// deliveryConfig is a published delivery config.
+type deliveryConfig struct {
+	Name         string                 `yaml:"name"`
+	Application  string                 `yaml:"application"`
+	Environments []*deliveryEnvironment `yaml:"environments"`
+	content      []byte
+	document     map[string]interface{}
+}
+
+type deliveryEnvironment struct {
+	Name      string                          `yaml:"name"`
+	Locations mdlib.DeliveryResourceLocations `yaml:"locations"`
+	Resources []*resource                     `yaml:"resources"`
+}
+
+// resource is a delivery config resource, the full document is kept so
+// everything under spec can be compared.
+type resource struct {
+	mdlib.DeliveryResource
+	id       string
+	document map[string]interface{}
+}
+
+// UnmarshalYAML satisfies yaml.Unmarshaler
+func (r *resource) UnmarshalYAML(node *yaml.Node) error {
+	if err := node.Decode(&r.DeliveryResource); err != nil {
+		return err
+	}
+	if r.Kind == "" {
+		return xerrors.Errorf("resource on line %d is missing kind", node.Line)
+	}
+	return node.Decode(&r.document)
+}
+
+func (r *resource) spec() interface{} {
+	return r.document["spec"]
+}
+
+func parseDeliveryConfig(content []byte) (*deliveryConfig, error) {
+	config := &deliveryConfig{content: content}
+	if err := yaml.Unmarshal(content, config); err != nil {
+		return nil, xerrors.Errorf("failed to parse delivery config: %w", err)
+	}
+	if err := yaml.Unmarshal(content, &config.document); err != nil {
+		return nil, xerrors.Errorf("failed to parse delivery config: %w", err)
+	}
+	for _, env := range config.Environments {
+		for _, r := range env.Resources {
+			// inherit the location from the env if the resource location is empty
+			if r.Spec.Locations.Empty() {
+				r.Spec.Locations = env.Locations
+			}
+			r.id = r.ID()
+		}
+	}
+	return config, nil
+}
+
+// SeedLive replaces the live state with the resources from content, which uses
+// the delivery config format.  Resources in the live state are reported as
+// current when calculating diffs and actuation plans.
+func (m *ManagedDelivery) SeedLive(content []byte) error {
+	config, err := parseDeliveryConfig(content)
+	if err != nil {
+		return err
+	}
+	live := map[string]*resource{}
+	for _, env := range config.Environments {
+		for _, r := range env.Resources {
+			live[r.id] = r
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.live = live
+	return nil
+}
+
+// Config returns the content of the delivery config published for the application.
+func (m *ManagedDelivery) Config(appName string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	config, ok := m.configs[appName]
+	if !ok {
+		return nil, false
+	}
+	return config.content, true
+}
+
+// Paused returns true if management of the application is paused.
+func (m *ManagedDelivery) Paused(appName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused[appName]
+}
+
+// ResourcePaused returns true if management of the resource is paused, either
+// individually or for the whole application.
+func (m *ManagedDelivery) ResourcePaused(appName, resourceID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paused[appName] || m.pausedResources[resourceID]
+}
+
+// Pinned returns the version of the artifact pinned in the environment.
+func (m *ManagedDelivery) Pinned(appName, envName, reference string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	version, ok := m.pins[artifactKey{appName, envName, reference}]
+	return version, ok
+}
+
+// Vetoed returns true if the artifact version has been vetoed, or marked as bad, in
+// the environment.
+func (m *ManagedDelivery) Vetoed(appName, envName, reference, version string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.vetoes[artifactVersionKey{artifactKey{appName, envName, reference}, version}]
+}
+
+// AddConstraintState adds a constraint state for the application, like a pending
+// manual-judgement for an artifact version.
+func (m *ManagedDelivery) AddConstraintState(appName string, state mdlib.ConstraintState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.constraints[appName] = append(m.constraints[appName], &state)
+}
+
+// ConstraintStates returns the constraint states for the application.
+func (m *ManagedDelivery) ConstraintStates(appName string) []mdlib.ConstraintState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := []mdlib.ConstraintState{}
+	for _, state := range m.constraints[appName] {
+		states = append(states, *state)
+	}
+	return states
+}
+
+// AddVerificationState adds a verification state for the application, like a passed
+// test container run for an artifact version.
+func (m *ManagedDelivery) AddVerificationState(appName string, state mdlib.VerificationState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.verifications[appName] = append(m.verifications[appName], state)
+}
+
+// AddResourceEvent adds an event to the history of the resource event.ID.  Events
+// are also recorded for resources actuated when using WithActuation.
+func (m *ManagedDelivery) AddResourceEvent(event mdlib.ResourceEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events[event.ID] = append(m.events[event.ID], event)
+}
+
+// recordEvent adds an event for the resource at the current time.  It must be
+// called with mu held.
+func (m *ManagedDelivery) recordEvent(appName string, res *resource, eventType string, update func(*mdlib.ResourceEvent)) {
+	event := mdlib.ResourceEvent{
+		Type:        eventType,
+		ID:          res.id,
+		Kind:        res.Kind,
+		Application: appName,
+		Timestamp:   time.Now(),
+	}
+	if update != nil {
+		update(&event)
+	}
+	m.events[res.id] = append(m.events[res.id], event)
+}
+
+// ServeHTTP satisfies http.Handler
+func (m *ManagedDelivery) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.converge()
+	m.mu.Unlock()
+
+	switch path := r.URL.Path; {
+	case path == deliveryConfigsPath && r.Method == http.MethodPost:
+		m.publish(w, r)
+	case path == deliveryConfigsPath+"/diff" && r.Method == http.MethodPost:
+		m.diff(w, r)
+	case path == deliveryConfigsPath+"/actuation-plan" && r.Method == http.MethodPost:
+		m.plan(w, r)
+	case path == deliveryConfigsPath+"/validate" && r.Method == http.MethodPost:
+		m.validate(w, r)
+	case strings.HasPrefix(path, deliveryConfigsPath+"/"):
+		m.deliveryConfig(w, r, strings.TrimPrefix(path, deliveryConfigsPath+"/"))
+	case strings.HasPrefix(path, applicationPath):
+		m.application(w, r, strings.Split(strings.TrimPrefix(path, applicationPath), "/"))
+	case strings.HasPrefix(path, resourcesPath+"events/") && r.Method == http.MethodGet:
+		m.resourceEvents(w, r, strings.TrimPrefix(path, resourcesPath+"events/"))
+	case strings.HasPrefix(path, resourcesPath) && strings.HasSuffix(path, "/pause"):
+		m.pause(w, r, m.pausedResources, strings.TrimSuffix(strings.TrimPrefix(path, resourcesPath), "/pause"))
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no fake handler for %s %s", r.Method, path))
+	}
+}
+
+// readConfig parses the delivery config from the request body, writing an error
+// response on failure.
+func readConfig(w http.ResponseWriter, r *http.Request) (*deliveryConfig, bool) {
+	content, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	config, err := parseDeliveryConfig(content)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if config.Application == "" {
+		writeError(w, http.StatusBadRequest, "delivery config is missing application")
+		return nil, false
+	}
+	return config, true
+}
+
+func (m *ManagedDelivery) publish(w http.ResponseWriter, r *http.Request) {
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+	force := r.URL.Query().Get("force") == "true"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.configs[config.Application]; !exists && !force {
+		// like Keel, refuse to take over resources already managed by
+		// another application unless forced
+		for _, env := range config.Environments {
+			for _, res := range env.Resources {
+				if owner := m.managedBy(res.id); owner != "" {
+					writeError(w, http.StatusConflict, fmt.Sprintf("resource %s is already managed by application %s", res.id, owner))
+					return
+				}
+			}
+		}
+	}
+	m.configs[config.Application] = config
+	m.converge()
+	writeJSON(w, http.StatusOK, config.document)
+}
+
+// managedBy returns the application managing the resource id.  It must be called
+// with mu held.
+func (m *ManagedDelivery) managedBy(id string) string {
+	for appName, config := range m.configs {
+		for _, env := range config.Environments {
+			for _, res := range env.Resources {
+				if res.id == id {
+					return appName
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (m *ManagedDelivery) deliveryConfig(w http.ResponseWriter, r *http.Request, appName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	config, ok := m.configs[appName]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no delivery config found for application %s", appName))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, config.document)
+	case http.MethodDelete:
+		delete(m.configs, appName)
+		delete(m.paused, appName)
+		writeJSON(w, http.StatusOK, config.document)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+	}
+}
+
+// pause updates the pause state for the application or resource key in paused.
+func (m *ManagedDelivery) pause(w http.ResponseWriter, r *http.Request, paused map[string]bool, key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch r.Method {
+	case http.MethodPost:
+		paused[key] = true
+	case http.MethodDelete:
+		delete(paused, key)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// application handles the /managed/application/{app} endpoints, parts is the
+// path split after the prefix.
+func (m *ManagedDelivery) application(w http.ResponseWriter, r *http.Request, parts []string) {
+	appName, action := parts[0], strings.Join(parts[1:], "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		m.summary(w, appName)
+	case action == "config":
+		m.deliveryConfig(w, r, appName)
+	case action == "pause":
+		m.pause(w, r, m.paused, appName)
+	case action == "pin" && r.Method == http.MethodPost:
+		m.updateArtifact(w, r, appName, func(target mdlib.EnvironmentArtifactVersion) {
+			m.pins[artifactKey{appName, target.TargetEnvironment, target.Reference}] = target.Version
+		})
+	case len(parts) == 3 && parts[1] == "pin" && r.Method == http.MethodDelete:
+		m.mu.Lock()
+		delete(m.pins, artifactKey{appName, parts[2], r.URL.Query().Get("reference")})
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	case (action == "veto" || action == "mark/bad") && r.Method == http.MethodPost:
+		m.updateArtifact(w, r, appName, func(target mdlib.EnvironmentArtifactVersion) {
+			m.vetoes[artifactVersionKey{artifactKey{appName, target.TargetEnvironment, target.Reference}, target.Version}] = true
+		})
+	case action == "mark/good" && r.Method == http.MethodPost:
+		m.updateArtifact(w, r, appName, func(target mdlib.EnvironmentArtifactVersion) {
+			delete(m.vetoes, artifactVersionKey{artifactKey{appName, target.TargetEnvironment, target.Reference}, target.Version})
+		})
+	case len(parts) == 4 && parts[1] == "environment" && parts[3] == "constraints" && r.Method == http.MethodGet:
+		m.constraintStates(w, appName, parts[2])
+	case len(parts) == 4 && parts[1] == "environment" && parts[3] == "verifications" && r.Method == http.MethodGet:
+		m.verificationStates(w, appName, parts[2])
+	case len(parts) == 4 && parts[1] == "environment" && parts[3] == "constraint" && r.Method == http.MethodPost:
+		m.updateConstraint(w, r, appName, parts[2])
+	case len(parts) == 5 && parts[1] == "veto" && r.Method == http.MethodDelete:
+		m.mu.Lock()
+		delete(m.vetoes, artifactVersionKey{artifactKey{appName, parts[2], parts[3]}, parts[4]})
+		m.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no fake handler for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func (m *ManagedDelivery) constraintStates(w http.ResponseWriter, appName, envName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := []*mdlib.ConstraintState{}
+	for _, state := range m.constraints[appName] {
+		if state.EnvironmentName == envName {
+			states = append(states, state)
+		}
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+func (m *ManagedDelivery) verificationStates(w http.ResponseWriter, appName, envName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	states := []mdlib.VerificationState{}
+	for _, state := range m.verifications[appName] {
+		if state.EnvironmentName == envName {
+			states = append(states, state)
+		}
+	}
+	writeJSON(w, http.StatusOK, states)
+}
+
+func (m *ManagedDelivery) updateConstraint(w http.ResponseWriter, r *http.Request, appName, envName string) {
+	update := mdlib.ConstraintStatusUpdate{}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, state := range m.constraints[appName] {
+		if state.EnvironmentName == envName &&
+			state.Type == update.Type &&
+			state.ArtifactVersion == update.ArtifactVersion &&
+			(update.ArtifactReference == "" || state.ArtifactReference == update.ArtifactReference) {
+			now := time.Now()
+			state.Status = update.Status
+			state.Comment = update.Comment
+			state.JudgedBy = "mdfake"
+			state.JudgedAt = &now
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("no %s constraint found for version %s in %s", update.Type, update.ArtifactVersion, envName))
+}
+
+// resourceEvents writes the events for the resource, newest first.
+func (m *ManagedDelivery) resourceEvents(w http.ResponseWriter, r *http.Request, resourceID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 10
+	}
+	history := m.events[resourceID]
+	events := []mdlib.ResourceEvent{}
+	for i := len(history) - 1; i >= 0 && len(events) < limit; i-- {
+		events = append(events, history[i])
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+type artifactKey struct {
+	appName, envName, reference string
+}
+
+type artifactVersionKey struct {
+	artifactKey
+	version string
+}
+
+// updateArtifact decodes the artifact version from the request body and applies
+// update with mu held.
+func (m *ManagedDelivery) updateArtifact(w http.ResponseWriter, r *http.Request, appName string, update func(mdlib.EnvironmentArtifactVersion)) {
+	target := mdlib.EnvironmentArtifactVersion{}
+	if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.configs[appName]; !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no delivery config found for application %s", appName))
+		return
+	}
+	update(target)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *ManagedDelivery) summary(w http.ResponseWriter, appName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	config, ok := m.configs[appName]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("no delivery config found for application %s", appName))
+		return
+	}
+	summary := &mdlib.ApplicationSummary{
+		ApplicationPaused: m.paused[appName],
+		Resources:         []mdlib.ResourceSummary{},
+		Environments:      []mdlib.EnvironmentSummary{},
+	}
+	for _, env := range config.Environments {
+		envSummary := mdlib.EnvironmentSummary{Name: env.Name, Resources: []string{}}
+		for _, res := range env.Resources {
+			summary.HasManagedResources = true
+			summary.Resources = append(summary.Resources, mdlib.ResourceSummary{
+				ID:        res.id,
+				Kind:      res.Kind,
+				Status:    m.resourceStatus(appName, res),
+				Moniker:   res.Spec.Moniker,
+				Locations: res.Spec.Locations,
+			})
+			envSummary.Resources = append(envSummary.Resources, res.id)
+		}
+		summary.Environments = append(summary.Environments, envSummary)
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// converge starts actuating managed resources that differ from the live state,
+// and updates the live state for resources that finished actuating.  It does
+// nothing unless WithActuation was used.  It must be called with mu held.
+func (m *ManagedDelivery) converge() {
+	if !m.actuate {
+		return
+	}
+	now := time.Now()
+	for appName, config := range m.configs {
+		for _, env := range config.Environments {
+			for _, res := range env.Resources {
+				if m.paused[appName] || m.pausedResources[res.id] {
+					delete(m.actuating, res.id)
+					continue
+				}
+				if done, ok := m.actuating[res.id]; ok {
+					if !now.Before(done) {
+						m.live[res.id] = res
+						delete(m.actuating, res.id)
+						m.recordEvent(appName, res, "ResourceDeltaResolved", nil)
+					}
+					continue
+				}
+				var current interface{}
+				live, exists := m.live[res.id]
+				if exists {
+					current = live.spec()
+				}
+				changes := compare(res.spec(), current)
+				if !exists || len(changes) > 0 {
+					m.actuating[res.id] = now.Add(m.actuationDelay)
+					m.recordEvent(appName, res, "ResourceDeltaDetected", func(event *mdlib.ResourceEvent) {
+						event.Delta = map[string]interface{}{}
+						for _, change := range changes {
+							event.Delta[change.Field] = map[string]string{
+								"state":   change.Type,
+								"desired": change.Desired,
+								"current": change.Current,
+							}
+						}
+					})
+					m.recordEvent(appName, res, "ResourceActuationLaunched", func(event *mdlib.ResourceEvent) {
+						event.Tasks = []mdlib.ResourceTask{{
+							ID:   fmt.Sprintf("mdfake-%d", now.UnixNano()),
+							Name: "Upsert " + res.id,
+						}}
+					})
+				}
+			}
+		}
+	}
+}
+
+// resourceStatus returns PAUSED for paused resources, ACTUATING for resources
+// being actuated, HAPPY when the live state matches the resource and DIFF
+// otherwise.  It must be called with mu held.
+func (m *ManagedDelivery) resourceStatus(appName string, res *resource) mdlib.ResourceStatus {
+	if m.paused[appName] || m.pausedResources[res.id] {
+		return "PAUSED"
+	}
+	if _, ok := m.actuating[res.id]; ok {
+		return "ACTUATING"
+	}
+	current, ok := m.live[res.id]
+	if !ok || len(compare(res.spec(), current.spec())) > 0 {
+		return "DIFF"
+	}
+	return "HAPPY"
+}
+
+func (m *ManagedDelivery) validate(w http.ResponseWriter, r *http.Request) {
+	if _, ok := readConfig(w, r); !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, []*mdlib.ValidationErrorDetail{})
+}
+
+// resourceDiff is the diff response for a single resource, unlike
+// mdlib.ManagedResourceDiff it contains the complete resource document.
+type resourceDiff struct {
+	Status     string                        `json:"status"`
+	ResourceID string                        `json:"resourceId"`
+	Resource   map[string]interface{}        `json:"resource"`
+	Diffs      map[string]mdlib.ResourceDiff `json:"diff,omitempty"`
+}
+
+type environmentDiff struct {
+	Name          string          `json:"name"`
+	ResourceDiffs []*resourceDiff `json:"resourceDiffs"`
+}
+
+func (m *ManagedDelivery) diff(w http.ResponseWriter, r *http.Request) {
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	envDiffs := []*environmentDiff{}
+	for _, env := range config.Environments {
+		envDiff := &environmentDiff{Name: env.Name, ResourceDiffs: []*resourceDiff{}}
+		for _, res := range env.Resources {
+			diff := &resourceDiff{
+				Status:     "NO_DIFF",
+				ResourceID: res.id,
+				Resource:   res.document,
+			}
+			current, ok := m.live[res.id]
+			if !ok {
+				diff.Status = "MISSING"
+			} else if changes := compare(res.spec(), current.spec()); len(changes) > 0 {
+				diff.Status = "DIFF"
+				diff.Diffs = map[string]mdlib.ResourceDiff{}
+				for _, change := range changes {
+					diff.Diffs[change.Field] = mdlib.ResourceDiff{
+						State:   change.Type,
+						Desired: change.Desired,
+						Current: change.Current,
+					}
+				}
+			}
+			envDiff.ResourceDiffs = append(envDiff.ResourceDiffs, diff)
+		}
+		envDiffs = append(envDiffs, envDiff)
+	}
+	writeJSON(w, http.StatusOK, envDiffs)
+}
+
+func (m *ManagedDelivery) plan(w http.ResponseWriter, r *http.Request) {
+	config, ok := readConfig(w, r)
+	if !ok {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	plan := &mdlib.ActuationPlan{
+		Application:      config.Application,
+		UpdatedAt:        time.Now(),
+		EnvironmentPlans: []mdlib.EnvironmentPlan{},
+	}
+	for _, env := range config.Environments {
+		envPlan := mdlib.EnvironmentPlan{Environment: env.Name}
+		for _, res := range env.Resources {
+			resourcePlan := mdlib.ResourcePlan{
+				Environment:         env.Name,
+				ResourceId:          res.id,
+				ResourceDisplayName: res.id,
+				IsManaged:           m.managedBy(res.id) != "",
+				IsPaused:            m.paused[config.Application] || m.pausedResources[res.id],
+				Action:              "NONE",
+			}
+			if current, ok := m.live[res.id]; ok {
+				resourcePlan.Diff = compare(res.spec(), current.spec())
+				if len(resourcePlan.Diff) > 0 {
+					resourcePlan.Action = "UPDATE"
+				}
+			} else {
+				resourcePlan.Action = "CREATE"
+				resourcePlan.Diff = compare(res.spec(), nil)
+			}
+			envPlan.ResourcePlans = append(envPlan.ResourcePlans, resourcePlan)
+		}
+		plan.EnvironmentPlans = append(plan.EnvironmentPlans, envPlan)
+	}
+	writeJSON(w, http.StatusOK, plan)
+}
+
+// compare returns the ADDED, CHANGED and REMOVED fields between the desired and
+// current values, fields are named by their path like `/capacity/desired`.
+func compare(desired, current interface{}) []mdlib.SingleResourceDiff {
+	desiredFields, currentFields := map[string]interface{}{}, map[string]interface{}{}
+	flatten("", desired, desiredFields)
+	flatten("", current, currentFields)
+
+	fields := []string{}
+	for field := range desiredFields {
+		fields = append(fields, field)
+	}
+	for field := range currentFields {
+		if _, ok := desiredFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diffs := []mdlib.SingleResourceDiff{}
+	for _, field := range fields {
+		desiredValue, hasDesired := desiredFields[field]
+		currentValue, hasCurrent := currentFields[field]
+		diff := mdlib.SingleResourceDiff{Field: field}
+		switch {
+		case !hasCurrent:
+			diff.Type = "ADDED"
+			diff.Desired = formatValue(desiredValue)
+		case !hasDesired:
+			diff.Type = "REMOVED"
+			diff.Current = formatValue(currentValue)
+		case !reflect.DeepEqual(desiredValue, currentValue):
+			diff.Type = "CHANGED"
+			diff.Desired = formatValue(desiredValue)
+			diff.Current = formatValue(currentValue)
+		default:
+			continue
+		}
+		diffs = append(diffs, diff)
+	}
+	return diffs
+}
+
+// flatten collects the leaf values of v keyed by path.  Empty maps and lists are
+// kept as leaf values so they can be compared.
+func flatten(path string, v interface{}, fields map[string]interface{}) {
+	switch value := v.(type) {
+	case nil:
+		if path != "" {
+			fields[path] = nil
+		}
+	case map[string]interface{}:
+		if len(value) == 0 && path != "" {
+			fields[path] = value
+		}
+		for k, item := range value {
+			flatten(path+"/"+k, item, fields)
+		}
+	case []interface{}:
+		if len(value) == 0 {
+			fields[path] = value
+		}
+		for i, item := range value {
+			flatten(fmt.Sprintf("%s/%d", path, i), item, fields)
+		}
+	default:
+		fields[path] = value
+	}
+}
+
+// formatValue returns the value for display, empty maps and lists are formatted
+// as JSON.
+func formatValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		content, _ := json.Marshal(v)
+		return string(content)
+	}
+	return fmt.Sprint(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(content)
+}
+
+// writeError writes a Spinnaker style error document.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	content, _ := json.Marshal(mdlib.ErrorEnvelope{
+		Status:  status,
+		Error:   http.StatusText(status),
+		Message: msg,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(content)
+}
@@ -0,0 +1,151 @@
+package mdfake
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/stretchr/testify/require"
+)
+
+var liveState = []byte(`
+application: myapp
+environments:
+- name: testing
+  resources:
+  - apiVersion: ec2.spinnaker.netflix.com/v1
+    kind: security-group
+    spec:
+      description: Security Group for myapp
+      inboundRules: []
+      locations:
+        account: test
+        regions:
+        - name: us-east-1
+        vpc: vpc0
+      moniker:
+        app: myapp
+  - kind: ec2/cluster@v1
+    spec:
+      deployWith:
+        maxServerGroups: 3
+      locations:
+        account: test
+      moniker:
+        app: myapp
+`)
+
+func TestManagedDelivery(t *testing.T) {
+	md := New()
+	require.NoError(t, md.SeedLive(liveState))
+	ts := httptest.NewServer(md)
+	defer ts.Close()
+
+	ctx := context.Background()
+	cli := mdlib.NewClient(mdlib.WithBaseURL(ts.URL))
+	processor := mdlib.NewDeliveryConfigProcessor(
+		mdlib.WithDirectory("../test-files/diff"),
+		mdlib.WithFile("spinnaker.yml"),
+	)
+
+	diffs, err := processor.DiffContext(ctx, cli)
+	require.NoError(t, err)
+	statuses := map[string]string{}
+	for _, diff := range diffs {
+		statuses[diff.ResourceID] = diff.Status
+	}
+	require.Equal(t, map[string]string{
+		"ec2:cluster:test:myapp":        "DIFF",
+		"titus:cluster:titustest:myapp": "MISSING",
+		"ec2:security-group:test:myapp": "NO_DIFF",
+	}, statuses)
+
+	plan, err := processor.PlanContext(ctx, cli)
+	require.NoError(t, err)
+	require.Len(t, plan.EnvironmentPlans, 1)
+	require.Equal(t, "myapp", plan.Application)
+	actions := map[string]mdlib.ResourceAction{}
+	for _, resourcePlan := range plan.EnvironmentPlans[0].ResourcePlans {
+		require.False(t, resourcePlan.IsManaged)
+		actions[resourcePlan.ResourceId] = resourcePlan.Action
+	}
+	require.Equal(t, map[string]mdlib.ResourceAction{
+		"ec2:cluster:test:myapp":        "UPDATE",
+		"titus:cluster:titustest:myapp": "CREATE",
+		"ec2:security-group:test:myapp": "NONE",
+	}, actions)
+
+	require.NoError(t, processor.PublishContext(ctx, cli, false))
+	_, ok := md.Config("myapp")
+	require.True(t, ok)
+
+	require.NoError(t, mdlib.PauseManagementContext(ctx, cli, "myapp"))
+	require.True(t, md.Paused("myapp"))
+	plan, err = processor.PlanContext(ctx, cli)
+	require.NoError(t, err)
+	for _, resourcePlan := range plan.EnvironmentPlans[0].ResourcePlans {
+		require.True(t, resourcePlan.IsManaged)
+		require.True(t, resourcePlan.IsPaused)
+	}
+	require.NoError(t, mdlib.ResumeManagementContext(ctx, cli, "myapp"))
+	require.False(t, md.Paused("myapp"))
+
+	require.NoError(t, processor.DeleteContext(ctx, cli))
+	_, ok = md.Config("myapp")
+	require.False(t, ok)
+
+	err = processor.DeleteContext(ctx, cli)
+	require.True(t, errors.Is(err, mdlib.ErrNotFound))
+}
+
+func TestCompare(t *testing.T) {
+	desired := map[string]interface{}{
+		"capacity": map[string]interface{}{"desired": 2},
+		"moniker":  map[string]interface{}{"app": "myapp"},
+		"tags":     []interface{}{"a"},
+	}
+	current := map[string]interface{}{
+		"capacity":  map[string]interface{}{"desired": 1},
+		"moniker":   map[string]interface{}{"app": "myapp"},
+		"overrides": map[string]interface{}{},
+	}
+	require.Equal(t, []mdlib.SingleResourceDiff{
+		{Field: "/capacity/desired", Type: "CHANGED", Desired: "2", Current: "1"},
+		{Field: "/overrides", Type: "REMOVED", Current: "{}"},
+		{Field: "/tags/0", Type: "ADDED", Desired: "a"},
+	}, compare(desired, current))
+}
+
+func TestArtifactVersions(t *testing.T) {
+	md := New()
+	ts := httptest.NewServer(md)
+	defer ts.Close()
+
+	ctx := context.Background()
+	cli := mdlib.NewClient(mdlib.WithBaseURL(ts.URL))
+	processor := mdlib.NewDeliveryConfigProcessor(
+		mdlib.WithDirectory("../test-files/diff"),
+		mdlib.WithFile("spinnaker.yml"),
+	)
+	require.NoError(t, processor.PublishContext(ctx, cli, false))
+
+	version := mdlib.EnvironmentArtifactVersion{
+		TargetEnvironment: "testing",
+		Reference:         "myapp",
+		Version:           "myapp-1.0.0",
+	}
+
+	require.NoError(t, mdlib.MarkArtifactVersionBadContext(ctx, cli, "myapp", version))
+	require.True(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.0"))
+	require.False(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.1"))
+
+	require.NoError(t, mdlib.MarkArtifactVersionGoodContext(ctx, cli, "myapp", version))
+	require.False(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.0"))
+
+	require.NoError(t, mdlib.VetoArtifactVersionContext(ctx, cli, "myapp", version))
+	require.True(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.0"))
+	require.NoError(t, mdlib.MarkArtifactVersionGood(cli, "myapp", version))
+	require.False(t, md.Vetoed("myapp", "testing", "myapp", "myapp-1.0.0"))
+}
@@ -0,0 +1,98 @@
+package mdtest
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RecordEnvVar is the environment variable to set to the base URL of a real
+// Spinnaker API to record the responses for all Servers, for example:
+//
+//	MDTEST_RECORD=https://gate.example.com go test ./...
+const RecordEnvVar = "MDTEST_RECORD"
+
+// Record is a ServerOption to proxy all requests to the Spinnaker API at target
+// and save the successful responses into the scenario directory, so the scenario
+// can be replayed later without access to the Spinnaker API.  The client is used
+// to send authenticated requests, http.DefaultClient is used if nil.
+func Record(target string, client func(*http.Request) (*http.Response, error)) ServerOption {
+	return func(s *Server) {
+		if client == nil {
+			client = http.DefaultClient.Do
+		}
+		s.recorder = &recorder{target: target, client: client}
+	}
+}
+
+type recorder struct {
+	target string
+	client func(*http.Request) (*http.Response, error)
+}
+
+func (rec *recorder) serve(t testing.TB, w http.ResponseWriter, r *http.Request, body []byte, responsesDir string) {
+	u := strings.TrimSuffix(rec.target, "/") + r.URL.RequestURI()
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, u, bytes.NewReader(body))
+	if err != nil {
+		t.Errorf("failed to create request for %s: %s", u, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	for _, h := range []string{"Accept", "Content-Type"} {
+		if v := r.Header.Get(h); v != "" {
+			req.Header.Set(h, v)
+		}
+	}
+
+	resp, err := rec.client(req)
+	if err != nil {
+		t.Errorf("failed to record %s %s: %s", r.Method, u, err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("failed to read response for %s %s: %s", r.Method, u, err)
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		err = saveResponse(responsesDir, r.Method, r.URL.Path, resp.Header.Get("Content-Type"), content)
+		if err != nil {
+			t.Errorf("failed to save response for %s %s: %s", r.Method, r.URL.Path, err)
+		}
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(content)
+}
+
+// saveResponse writes content into the scenario layout, replacing any existing
+// response for the method and path.
+func saveResponse(responsesDir, method, path, ct string, content []byte) error {
+	dir := filepath.Join(responsesDir, filepath.FromSlash(path))
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	ext := ".json"
+	if mediaType, _, _ := mime.ParseMediaType(ct); strings.Contains(mediaType, "yaml") {
+		ext = ".yml"
+	}
+	for _, old := range []string{".json", ".yml"} {
+		if old != ext {
+			os.Remove(filepath.Join(dir, method+old))
+		}
+	}
+	return ioutil.WriteFile(filepath.Join(dir, method+ext), content, 0o644)
+}
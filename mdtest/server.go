@@ -0,0 +1,252 @@
+// Package mdtest provides a fake Spinnaker API (Gate) for testing code built with
+// mdlib and mdcli.
+//
+// Responses are loaded from a scenario directory laid out by request path and
+// method:
+//
+//	<scenario>/responses/<path>/<METHOD>.json
+//	<scenario>/responses/<path>/<METHOD>.yml
+//
+// Requests without a matching file get a 404 response.  An optional
+// <METHOD>.request.json or <METHOD>.request.yml file next to the response is
+// compared to the request body, any difference fails the test.
+package mdtest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Request is a request received by the Server.
+type Request struct {
+	Method   string
+	Path     string
+	RawQuery string
+	Header   http.Header
+	Body     []byte
+}
+
+// String returns the request as "METHOD /path"
+func (r Request) String() string {
+	return fmt.Sprintf("%s %s", r.Method, r.Path)
+}
+
+// Server is a fake Spinnaker API serving responses from a scenario directory.
+type Server struct {
+	*httptest.Server
+	t           testing.TB
+	scenarioDir string
+	recorder    *recorder
+
+	mu       sync.Mutex
+	requests []Request
+}
+
+// ServerOption is used to configure a Server via NewServer.
+type ServerOption func(*Server)
+
+// NewServer starts a Server for the scenario directory, for example
+// "../test-files/export".  The server is closed when the test completes.
+func NewServer(t testing.TB, scenarioDir string, opts ...ServerOption) *Server {
+	t.Helper()
+	s := &Server{
+		t:           t,
+		scenarioDir: scenarioDir,
+	}
+	if target := os.Getenv(RecordEnvVar); target != "" {
+		s.recorder = &recorder{target: target, client: http.DefaultClient.Do}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// ResponsesDir returns the directory responses are loaded from.
+func (s *Server) ResponsesDir() string {
+	return filepath.Join(s.scenarioDir, "responses")
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.t.Errorf("failed to read request body for %s %s: %s", r.Method, r.URL.Path, err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := Request{
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		RawQuery: r.URL.RawQuery,
+		Header:   r.Header.Clone(),
+		Body:     body,
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	s.mu.Unlock()
+
+	if s.recorder != nil {
+		s.recorder.serve(s.t, w, r, body, s.ResponsesDir())
+		return
+	}
+
+	dir := filepath.Join(s.ResponsesDir(), filepath.FromSlash(r.URL.Path))
+	if expectedPath, ok := findFile(dir, r.Method+".request"); ok {
+		if msg := matchBody(expectedPath, body); msg != "" {
+			s.t.Errorf("unexpected request body for %s: %s", req, msg)
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(msg))
+			return
+		}
+	}
+
+	responsePath, ok := findFile(dir, r.Method)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	content, err := ioutil.ReadFile(responsePath)
+	if err != nil {
+		s.t.Errorf("failed to read response %s: %s", responsePath, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType(responsePath))
+	w.WriteHeader(http.StatusOK)
+	w.Write(content)
+}
+
+// findFile returns the path to the json or yml file with base name in dir.
+func findFile(dir, name string) (string, bool) {
+	for _, ext := range []string{".json", ".yml"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func contentType(path string) string {
+	if strings.HasSuffix(path, ".yml") {
+		return "application/x-yaml"
+	}
+	return "application/json"
+}
+
+// matchBody compares the request body to the content of expectedPath, returning a
+// description of the mismatch or an empty string if they are equivalent.
+func matchBody(expectedPath string, body []byte) string {
+	expected, err := ioutil.ReadFile(expectedPath)
+	if err != nil {
+		return fmt.Sprintf("failed to read %s: %s", expectedPath, err)
+	}
+	if BodyEqual(expected, body) {
+		return ""
+	}
+	return fmt.Sprintf("expected body from %s:\n%s\ngot:\n%s", expectedPath, expected, body)
+}
+
+// BodyEqual returns true if the expected and actual content are equivalent.  JSON
+// and YAML content is compared by value, so formatting and key order are
+// ignored, other content must match exactly.
+func BodyEqual(expected, actual []byte) bool {
+	if bytes.Equal(expected, actual) {
+		return true
+	}
+	var expectedValue, actualValue interface{}
+	if err := yaml.Unmarshal(expected, &expectedValue); err != nil {
+		return false
+	}
+	if err := yaml.Unmarshal(actual, &actualValue); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(expectedValue, actualValue)
+}
+
+// Requests returns all the requests received by the server in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+// RequestCounts returns the number of requests received keyed by "METHOD /path"
+func (s *Server) RequestCounts() map[string]int {
+	counts := map[string]int{}
+	for _, req := range s.Requests() {
+		counts[req.String()]++
+	}
+	return counts
+}
+
+// LastRequest returns the most recent request for method and path.
+func (s *Server) LastRequest(method, path string) (Request, bool) {
+	requests := s.Requests()
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].Method == method && requests[i].Path == path {
+			return requests[i], true
+		}
+	}
+	return Request{}, false
+}
+
+// AssertRequests fails the test if the requests received do not match the
+// expected counts keyed by "METHOD /path"
+func (s *Server) AssertRequests(t testing.TB, expected map[string]int) {
+	t.Helper()
+	got := s.RequestCounts()
+	if reflect.DeepEqual(expected, got) {
+		return
+	}
+	t.Errorf("unexpected requests:\n%s", diffCounts(expected, got))
+}
+
+// AssertBody fails the test if the body of the most recent request for method and
+// path does not match the expected content, see BodyEqual.
+func (s *Server) AssertBody(t testing.TB, method, path string, expected []byte) {
+	t.Helper()
+	req, ok := s.LastRequest(method, path)
+	if !ok {
+		t.Errorf("no request received for %s %s", method, path)
+		return
+	}
+	if !BodyEqual(expected, req.Body) {
+		t.Errorf("unexpected body for %s, expected:\n%s\ngot:\n%s", req, expected, req.Body)
+	}
+}
+
+func diffCounts(expected, got map[string]int) string {
+	keys := []string{}
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	for k := range got {
+		if _, ok := expected[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	lines := []string{}
+	for _, k := range keys {
+		if expected[k] != got[k] {
+			lines = append(lines, fmt.Sprintf("  %s: expected %d got %d", k, expected[k], got[k]))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
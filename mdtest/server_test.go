@@ -0,0 +1,88 @@
+package mdtest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerRecordAndReplay(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "mdtest")
+	require.NoError(t, err)
+	defer os.RemoveAll(tdir)
+
+	upstream := NewServer(t, "../test-files/export")
+
+	recording := NewServer(t, tdir, Record(upstream.URL, nil))
+	resp, err := http.Get(recording.URL + "/applications/myapp/serverGroups")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(recording.URL + "/applications/missing/serverGroups")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	expected, err := ioutil.ReadFile("../test-files/export/responses/applications/myapp/serverGroups/GET.json")
+	require.NoError(t, err)
+	got, err := ioutil.ReadFile(filepath.Join(tdir, "responses/applications/myapp/serverGroups/GET.json"))
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(got))
+
+	// failed responses are not recorded
+	_, err = os.Stat(filepath.Join(tdir, "responses/applications/missing"))
+	require.True(t, os.IsNotExist(err))
+
+	replay := NewServer(t, tdir)
+	resp, err = http.Get(replay.URL + "/applications/myapp/serverGroups")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	got, err = ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(got))
+	replay.AssertRequests(t, map[string]int{
+		"GET /applications/myapp/serverGroups": 1,
+	})
+}
+
+func TestServerBodyMatch(t *testing.T) {
+	ft := &fakeT{TB: t}
+	s := NewServer(ft, "../test-files/diff")
+
+	expected, err := ioutil.ReadFile("../test-files/diff/spinnaker.yml")
+	require.NoError(t, err)
+
+	resp, err := http.Post(s.URL+"/managed/delivery-configs/diff", "application/x-yaml", strings.NewReader(string(expected)))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, ft.errors)
+	s.AssertBody(t, http.MethodPost, "/managed/delivery-configs/diff", expected)
+
+	resp, err = http.Post(s.URL+"/managed/delivery-configs/diff", "application/x-yaml", strings.NewReader("name: other"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Len(t, ft.errors, 1)
+}
+
+func TestBodyEqual(t *testing.T) {
+	require.True(t, BodyEqual([]byte(`{"a": 1, "b": [true]}`), []byte("b:\n- true\na: 1\n")))
+	require.False(t, BodyEqual([]byte(`{"a": 1}`), []byte(`{"a": 2}`)))
+}
+
+// fakeT records errors instead of failing the test.
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, format)
+}
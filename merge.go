@@ -0,0 +1,364 @@
+package mdlib
+
+import (
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MergeConflict is a value in the delivery config that was changed both locally and
+// in Spinnaker since the last publish, so the changes cannot be merged.
+type MergeConflict struct {
+	// Path locates the value in the delivery config, list items are identified
+	// by their resource id, artifact reference or name, like
+	// `/environments/test/resources/ec2:cluster:test:myapp/spec/capacity`.
+	Path []string
+	// Base, Local and Remote are the conflicting values, they are nil when the
+	// value is not present.
+	Base   *yaml.Node
+	Local  *yaml.Node
+	Remote *yaml.Node
+}
+
+// String returns the path of the conflict
+func (c MergeConflict) String() string {
+	return "/" + strings.Join(c.Path, "/")
+}
+
+// serverManagedPaths are fields Spinnaker adds to the stored delivery config, the local
+// value is always kept for these.
+var serverManagedPaths = [][]string{
+	{"apiVersion"},
+	{"metadata"},
+	{"rawConfig"},
+	{"artifacts", "*", "deliveryConfigName"},
+	{"environments", "*", "resources", "*", "id"},
+	{"environments", "*", "resources", "*", "metadata"},
+}
+
+// MergeDeliveryConfig performs a three-way merge of the delivery config documents,
+// changes from base made in either local or remote are applied to the result.
+// Lists of resources, artifacts, environments and other named items are merged by
+// item, other lists are merged as a single value.  When a value was changed in both
+// local and remote the local value is kept and a MergeConflict is returned for it.
+// base may be nil if there is no common ancestor, then values only present in local
+// or remote are kept and values that differ conflict.
+func MergeDeliveryConfig(base, local, remote *yaml.Node) (*yaml.Node, []MergeConflict) {
+	m := &merger{envLocations: map[*yaml.Node]DeliveryResourceLocations{}}
+	for _, node := range []*yaml.Node{base, local, remote} {
+		m.indexResources(unwrapDocument(node))
+	}
+	merged := m.merge(nil, unwrapDocument(base), unwrapDocument(local), unwrapDocument(remote))
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	if local != nil && local.Kind == yaml.DocumentNode {
+		copied := *local
+		doc = &copied
+	}
+	doc.Content = []*yaml.Node{merged}
+	return doc, m.conflicts
+}
+
+type merger struct {
+	conflicts []MergeConflict
+	// envLocations are the environment locations for each resource item, resources
+	// without locations use them like EnvironmentResources.
+	envLocations map[*yaml.Node]DeliveryResourceLocations
+}
+
+// indexResources records the environment locations for the resources in the delivery
+// config root.
+func (m *merger) indexResources(root *yaml.Node) {
+	environments := mappingValue(root, "environments")
+	if !isKind(environments, yaml.SequenceNode) {
+		return
+	}
+	for _, env := range environments.Content {
+		locations := DeliveryResourceLocations{}
+		if node := mappingValue(env, "locations"); node != nil {
+			if err := node.Decode(&locations); err != nil {
+				continue
+			}
+		}
+		resources := mappingValue(env, "resources")
+		if !isKind(resources, yaml.SequenceNode) {
+			continue
+		}
+		for _, item := range resources.Content {
+			m.envLocations[item] = locations
+		}
+	}
+}
+
+func (m *merger) merge(path []string, base, local, remote *yaml.Node) *yaml.Node {
+	// always merge mappings and lists by item so server managed fields are
+	// kept from local
+	if isKind(local, yaml.MappingNode) && isKind(remote, yaml.MappingNode) {
+		return m.mergeMapping(path, base, local, remote)
+	}
+	if isKind(local, yaml.SequenceNode) && isKind(remote, yaml.SequenceNode) {
+		if merged, ok := m.mergeSequence(path, base, local, remote); ok {
+			return merged
+		}
+	}
+	switch {
+	case nodeEqual(local, remote):
+		return local
+	case nodeEqual(base, local):
+		return remote
+	case nodeEqual(base, remote):
+		return local
+	}
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Path:   path,
+		Base:   base,
+		Local:  local,
+		Remote: remote,
+	})
+	return local
+}
+
+func (m *merger) mergeMapping(path []string, base, local, remote *yaml.Node) *yaml.Node {
+	merged := *local
+	merged.Content = nil
+
+	keys := mappingKeys(local)
+	for _, key := range mappingKeys(remote) {
+		if mappingValue(local, key.Value) == nil {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		keyPath := append(path[:len(path):len(path)], key.Value)
+		localValue := mappingValue(local, key.Value)
+		value := localValue
+		if !isServerManaged(keyPath) {
+			value = m.merge(keyPath, mappingValue(base, key.Value), localValue, mappingValue(remote, key.Value))
+		}
+		if value != nil {
+			merged.Content = append(merged.Content, key, value)
+		}
+	}
+	return &merged
+}
+
+func (m *merger) mergeSequence(path []string, base, local, remote *yaml.Node) (*yaml.Node, bool) {
+	_, baseItems, ok := m.sequenceItems(base)
+	if !ok {
+		return nil, false
+	}
+	localIDs, localItems, ok := m.sequenceItems(local)
+	if !ok {
+		return nil, false
+	}
+	remoteIDs, remoteItems, ok := m.sequenceItems(remote)
+	if !ok {
+		return nil, false
+	}
+
+	merged := *local
+	merged.Content = nil
+
+	ids := localIDs
+	for _, id := range remoteIDs {
+		if _, ok := localItems[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		itemPath := append(path[:len(path):len(path)], id)
+		localItem := localItems[id]
+		baseItem := m.dropInheritedLocations(localItem, baseItems[id])
+		remoteItem := m.dropInheritedLocations(localItem, remoteItems[id])
+		value := m.merge(itemPath, baseItem, localItem, remoteItem)
+		if value != nil {
+			merged.Content = append(merged.Content, value)
+		}
+	}
+	return &merged, true
+}
+
+// sequenceItems returns the ids of the items in the sequence, in order, along with the
+// items by id.  ok is false if any item cannot be identified.
+func (m *merger) sequenceItems(node *yaml.Node) (ids []string, items map[string]*yaml.Node, ok bool) {
+	items = map[string]*yaml.Node{}
+	if node == nil {
+		return nil, items, true
+	}
+	if node.Kind != yaml.SequenceNode {
+		return nil, nil, false
+	}
+	for _, item := range node.Content {
+		id := m.itemID(item)
+		if id == "" {
+			return nil, nil, false
+		}
+		if _, ok := items[id]; ok {
+			return nil, nil, false
+		}
+		ids = append(ids, id)
+		items[id] = item
+	}
+	return ids, items, true
+}
+
+// itemID returns the resource id for resources, otherwise the reference or name of the
+// item.  An empty string is returned if the item has none of these.
+func (m *merger) itemID(item *yaml.Node) string {
+	if !isKind(item, yaml.MappingNode) {
+		return ""
+	}
+	if mappingValue(item, "kind") != nil && mappingValue(item, "spec") != nil {
+		resource := DeliveryResource{}
+		if err := item.Decode(&resource); err != nil {
+			return ""
+		}
+		if resource.Spec.Locations.Empty() {
+			resource.Spec.Locations = m.envLocations[item]
+		}
+		return resource.ID()
+	}
+	for _, key := range []string{"reference", "name"} {
+		if value := mappingValue(item, key); isKind(value, yaml.ScalarNode) && value.Value != "" {
+			return value.Value
+		}
+	}
+	return ""
+}
+
+// dropInheritedLocations returns item without spec.locations when the local resource
+// uses the locations of its environment and item has the same locations, like the
+// resources from Spinnaker which always have their locations filled in.
+func (m *merger) dropInheritedLocations(local, item *yaml.Node) *yaml.Node {
+	envLocations, ok := m.envLocations[local]
+	if !ok || mappingValue(mappingValue(local, "spec"), "locations") != nil {
+		return item
+	}
+	spec := mappingValue(item, "spec")
+	node := mappingValue(spec, "locations")
+	if node == nil {
+		return item
+	}
+	locations := DeliveryResourceLocations{}
+	if err := node.Decode(&locations); err != nil || !reflect.DeepEqual(locations, envLocations) {
+		return item
+	}
+	return withMappingValue(item, "spec", withMappingValue(spec, "locations", nil))
+}
+
+func isServerManaged(path []string) bool {
+	for _, managed := range serverManagedPaths {
+		if len(managed) != len(path) {
+			continue
+		}
+		match := true
+		for i, segment := range managed {
+			if segment != "*" && segment != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeEqual returns true if the nodes have the same value, ignoring style and comments.
+func nodeEqual(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	var aValue, bValue interface{}
+	if err := a.Decode(&aValue); err != nil {
+		return false
+	}
+	if err := b.Decode(&bValue); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(aValue, bValue)
+}
+
+func isKind(node *yaml.Node, kind yaml.Kind) bool {
+	return node != nil && node.Kind == kind
+}
+
+func unwrapDocument(node *yaml.Node) *yaml.Node {
+	if isKind(node, yaml.DocumentNode) && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+	return node
+}
+
+func mappingKeys(node *yaml.Node) []*yaml.Node {
+	keys := []*yaml.Node{}
+	if !isKind(node, yaml.MappingNode) {
+		return keys
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i])
+	}
+	return keys
+}
+
+// mappingValue returns the value for key, or nil if node is not a mapping or does
+// not have the key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if !isKind(node, yaml.MappingNode) {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// withMappingValue returns a copy of the mapping node with the value for key replaced,
+// the key is removed when value is nil.
+func withMappingValue(node *yaml.Node, key string, value *yaml.Node) *yaml.Node {
+	copied := *node
+	copied.Content = nil
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			copied.Content = append(copied.Content, node.Content[i], node.Content[i+1])
+		} else if value != nil {
+			copied.Content = append(copied.Content, node.Content[i], value)
+		}
+	}
+	return &copied
+}
+
+// normalizeRemote prepares the delivery config from Spinnaker to be merged, the server
+// managed fields are removed and the style is reset so content parsed from JSON is
+// written as block style YAML.
+func normalizeRemote(path []string, node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	node.Style = 0
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			normalizeRemote(path, child)
+		}
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			normalizeRemote(append(path[:len(path):len(path)], "*"), child)
+		}
+	case yaml.MappingNode:
+		content := []*yaml.Node{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyPath := append(path[:len(path):len(path)], node.Content[i].Value)
+			if isServerManaged(keyPath) {
+				continue
+			}
+			normalizeRemote(nil, node.Content[i])
+			normalizeRemote(keyPath, node.Content[i+1])
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+		node.Content = content
+	}
+}
@@ -0,0 +1,264 @@
+package mdlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeDeliveryConfig(t *testing.T) {
+	parse := func(content string) *yaml.Node {
+		node := &yaml.Node{}
+		require.NoError(t, yaml.Unmarshal([]byte(content), node))
+		return node
+	}
+
+	base := parse(`
+application: myapp
+artifacts:
+- name: myapp
+  reference: myapp
+  type: deb
+environments:
+- name: test
+  resources:
+  - kind: ec2/cluster@v1
+    spec:
+      moniker:
+        app: myapp
+      locations:
+        account: test
+      capacity:
+        desired: 1
+  - kind: ec2/security-group@v1
+    spec:
+      moniker:
+        app: myapp
+      locations:
+        account: test
+`)
+	local := parse(`
+application: myapp
+# local comments are kept
+artifacts:
+- name: myapp
+  reference: myapp
+  type: deb
+environments:
+- name: test
+  resources:
+  - kind: ec2/cluster@v1
+    spec:
+      moniker:
+        app: myapp
+      locations:
+        account: test
+      capacity:
+        desired: 2
+  - kind: ec2/security-group@v1
+    spec:
+      moniker:
+        app: myapp
+      locations:
+        account: test
+`)
+	// remote is returned as JSON with server managed fields
+	remote := parse(`{
+  "application": "myapp",
+  "metadata": {"createdAt": "2020-01-01"},
+  "artifacts": [{"name": "myapp", "reference": "myapp", "type": "deb", "deliveryConfigName": "myapp"}],
+  "environments": [{
+    "name": "test",
+    "constraints": [{"type": "manual-judgement"}],
+    "resources": [{
+      "id": "ec2:cluster:test:myapp",
+      "kind": "ec2/cluster@v1",
+      "spec": {
+        "moniker": {"app": "myapp"},
+        "locations": {"account": "test"},
+        "capacity": {"desired": 1}
+      }
+    }, {
+      "kind": "ec2/load-balancer@v1",
+      "spec": {
+        "moniker": {"app": "myapp"},
+        "locations": {"account": "test"}
+      }
+    }]
+  }]
+}`)
+	normalizeRemote(nil, remote)
+
+	merged, conflicts := MergeDeliveryConfig(base, local, remote)
+	require.Empty(t, conflicts)
+
+	content, err := yaml.Marshal(merged)
+	require.NoError(t, err)
+	require.Equal(t, `application: myapp
+# local comments are kept
+artifacts:
+    - name: myapp
+      reference: myapp
+      type: deb
+environments:
+    - name: test
+      resources:
+        - kind: ec2/cluster@v1
+          spec:
+            moniker:
+                app: myapp
+            locations:
+                account: test
+            capacity:
+                desired: 2
+        - kind: ec2/load-balancer@v1
+          spec:
+            moniker:
+                app: myapp
+            locations:
+                account: test
+      constraints:
+        - type: manual-judgement
+`, string(content))
+
+	// the same value changed both locally and remotely conflicts
+	remote = parse(`
+application: myapp
+artifacts:
+- name: myapp
+  reference: myapp
+  type: deb
+environments:
+- name: test
+  resources:
+  - kind: ec2/cluster@v1
+    spec:
+      moniker:
+        app: myapp
+      locations:
+        account: test
+      capacity:
+        desired: 3
+`)
+	merged, conflicts = MergeDeliveryConfig(base, local, remote)
+	require.Len(t, conflicts, 1)
+	require.Equal(t, "/environments/test/resources/ec2:cluster:test:myapp/spec/capacity/desired", conflicts[0].String())
+	require.Equal(t, "1", conflicts[0].Base.Value)
+	require.Equal(t, "2", conflicts[0].Local.Value)
+	require.Equal(t, "3", conflicts[0].Remote.Value)
+
+	// without a base items only present on one side are kept, values that
+	// differ conflict
+	merged, conflicts = MergeDeliveryConfig(nil, local, remote)
+	require.Len(t, conflicts, 1)
+	require.Equal(t, "/environments/test/resources/ec2:cluster:test:myapp/spec/capacity/desired", conflicts[0].String())
+	require.True(t, nodeEqual(local, merged))
+}
+
+func TestMergeDeliveryConfigEnvironmentLocations(t *testing.T) {
+	parse := func(content string) *yaml.Node {
+		node := &yaml.Node{}
+		require.NoError(t, yaml.Unmarshal([]byte(content), node))
+		return node
+	}
+
+	// the resources use the locations of the environment
+	base := parse(`
+application: myapp
+environments:
+- name: test
+  locations:
+    account: test
+    regions:
+    - name: us-east-1
+  resources:
+  - kind: ec2/cluster@v1
+    spec:
+      moniker:
+        app: myapp
+      capacity:
+        desired: 1
+  - kind: ec2/security-group@v1
+    spec:
+      moniker:
+        app: myapp
+`)
+	local := parse(`
+application: myapp
+environments:
+- name: test
+  locations:
+    account: test
+    regions:
+    - name: us-east-1
+  resources:
+  - kind: ec2/cluster@v1
+    spec:
+      moniker:
+        app: myapp
+      capacity:
+        desired: 2
+  - kind: ec2/security-group@v1
+    spec:
+      moniker:
+        app: myapp
+`)
+	// spinnaker fills in the locations of each resource
+	remote := parse(`{
+  "application": "myapp",
+  "environments": [{
+    "name": "test",
+    "locations": {"account": "test", "regions": [{"name": "us-east-1"}]},
+    "resources": [{
+      "id": "ec2:cluster:test:myapp",
+      "kind": "ec2/cluster@v1",
+      "spec": {
+        "moniker": {"app": "myapp"},
+        "locations": {"account": "test", "regions": [{"name": "us-east-1"}]},
+        "capacity": {"desired": 1}
+      }
+    }, {
+      "id": "ec2:security-group:test:myapp",
+      "kind": "ec2/security-group@v1",
+      "spec": {
+        "moniker": {"app": "myapp"},
+        "locations": {"account": "test", "regions": [{"name": "us-east-1"}]},
+        "description": "changed in spinnaker"
+      }
+    }]
+  }]
+}`)
+	normalizeRemote(nil, remote)
+
+	merged, conflicts := MergeDeliveryConfig(base, local, remote)
+	require.Empty(t, conflicts)
+
+	content, err := yaml.Marshal(merged)
+	require.NoError(t, err)
+	require.Equal(t, `application: myapp
+environments:
+    - name: test
+      locations:
+        account: test
+        regions:
+            - name: us-east-1
+      resources:
+        - kind: ec2/cluster@v1
+          spec:
+            moniker:
+                app: myapp
+            capacity:
+                desired: 2
+        - kind: ec2/security-group@v1
+          spec:
+            moniker:
+                app: myapp
+            description: changed in spinnaker
+`, string(content))
+
+	// the base recorded by a previous pull also has the locations filled in
+	merged, conflicts = MergeDeliveryConfig(remote, local, remote)
+	require.Empty(t, conflicts)
+	require.True(t, nodeEqual(local, merged))
+}
@@ -0,0 +1,121 @@
+package mdlib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"strings"
+	"time"
+)
+
+// Doer is the function signature used to send a request to the Spinnaker API, http.Client.Do
+// implements this.
+type Doer func(*http.Request) (*http.Response, error)
+
+// Middleware wraps a Doer to inspect or modify requests and responses.  The request body is
+// always rewindable via req.GetBody.
+type Middleware func(next Doer) Doer
+
+// WithMiddleware is a ClientOpt to add middleware via NewClient.  Middleware is applied in
+// order, the first Middleware sees the request first and the response last.  Middleware is
+// called for each attempt when requests are retried, after any Authenticators have been
+// applied.
+func WithMiddleware(m ...Middleware) ClientOpt {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, m...)
+	}
+}
+
+// redactedHeaders are headers that will not be logged by DebugMiddleware.
+var redactedHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+func redactHeader(h http.Header) http.Header {
+	redacted := h.Clone()
+	for _, name := range redactedHeaders {
+		if _, ok := redacted[name]; ok {
+			redacted.Set(name, "REDACTED")
+		}
+	}
+	return redacted
+}
+
+// DebugMiddleware will log the full request and response for each API request along with
+// the request duration.  Credentials in Authorization and cookie headers are redacted.  JSON
+// responses are pretty-printed.
+func DebugMiddleware(log Logger) Middleware {
+	return func(next Doer) Doer {
+		return func(req *http.Request) (*http.Response, error) {
+			header := req.Header
+			req.Header = redactHeader(header)
+			out, _ := httputil.DumpRequest(req, true)
+			req.Header = header
+			log.Printf("%s", out)
+
+			start := time.Now()
+			resp, err := next(req)
+			if err != nil {
+				log.Printf("%s %s failed after %s: %s", req.Method, req.URL, time.Since(start), err)
+				return resp, err
+			}
+			log.Printf("%s %s completed in %s", req.Method, req.URL, time.Since(start))
+
+			header = resp.Header
+			resp.Header = redactHeader(header)
+			if strings.HasPrefix(header.Get("Content-Type"), "application/json") {
+				out, _ = httputil.DumpResponse(resp, false)
+				content, _ := ioutil.ReadAll(resp.Body)
+				resp.Body = ioutil.NopCloser(bytes.NewReader(content))
+				var data interface{}
+				if err := json.Unmarshal(content, &data); err == nil {
+					content, _ = json.MarshalIndent(data, "", "  ")
+				}
+				out = append(out, content...)
+			} else {
+				out, _ = httputil.DumpResponse(resp, true)
+			}
+			resp.Header = header
+			log.Printf("%s", out)
+			return resp, nil
+		}
+	}
+}
+
+// UserAgentMiddleware will set the User-Agent header on each request.
+func UserAgentMiddleware(userAgent string) Middleware {
+	return func(next Doer) Doer {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("User-Agent", userAgent)
+			return next(req)
+		}
+	}
+}
+
+// DefaultCorrelationIDHeader is the header used by CorrelationIDMiddleware, it is the header
+// Spinnaker services use to correlate logs for a request.
+var DefaultCorrelationIDHeader = "X-SPINNAKER-REQUEST-ID"
+
+// CorrelationIDMiddleware will set a unique id in the DefaultCorrelationIDHeader for each
+// request, unless the header has already been set.  If newID is nil a random id is used.
+func CorrelationIDMiddleware(newID func() string) Middleware {
+	if newID == nil {
+		newID = randomID
+	}
+	return func(next Doer) Doer {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(DefaultCorrelationIDHeader) == "" {
+				req.Header.Set(DefaultCorrelationIDHeader, newID())
+			}
+			return next(req)
+		}
+	}
+}
+
+func randomID() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,61 @@
+package mdlib
+
+import (
+	"regexp"
+	"strings"
+)
+
+// OwnershipPolicy decides which shared resources found for an application, like load
+// balancers and security groups, belong to the application and are offered for export.
+// Deny is checked first, then a resource is owned if any of the other rules match.
+type OwnershipPolicy struct {
+	// NamePrefixes owns resources with names starting with any of the prefixes.
+	NamePrefixes []string
+	// Patterns owns resources with names matching any of the regular expressions.
+	Patterns []*regexp.Regexp
+	// Allow owns resources with any of these exact names.
+	Allow []string
+	// Deny never owns resources with any of these exact names, it takes precedence
+	// over all other rules.
+	Deny []string
+	// Applications owns resources that Spinnaker associates with any of these
+	// applications, from the resource moniker or application metadata.
+	Applications []string
+}
+
+// DefaultOwnershipPolicy returns the policy used when none is set, it owns resources
+// that Spinnaker associates with the application and resources named like the
+// application, `appName` or `appName-something` but not `appName2`.
+func DefaultOwnershipPolicy(appName string) *OwnershipPolicy {
+	return &OwnershipPolicy{
+		NamePrefixes: []string{appName + "-"},
+		Allow:        []string{appName},
+		Applications: []string{appName},
+	}
+}
+
+// Owns returns true if the resource with name belongs to the application.  The
+// application is the Spinnaker application metadata for the resource, it is empty if
+// not known.
+func (p *OwnershipPolicy) Owns(name, application string) bool {
+	if containsString(p.Deny, name) {
+		return false
+	}
+	if containsString(p.Allow, name) {
+		return true
+	}
+	if application != "" && containsString(p.Applications, application) {
+		return true
+	}
+	for _, prefix := range p.NamePrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for _, rx := range p.Patterns {
+		if rx.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package mdlib
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnershipPolicy(t *testing.T) {
+	policy := DefaultOwnershipPolicy("myapp")
+	require.True(t, policy.Owns("myapp", ""))
+	require.True(t, policy.Owns("myapp-elb", ""))
+	require.False(t, policy.Owns("myapp2", ""))
+	require.False(t, policy.Owns("legacy_myapp", ""))
+	// spinnaker associates it with the application
+	require.True(t, policy.Owns("legacy_myapp", "myapp"))
+	require.False(t, policy.Owns("legacy_myapp", "otherapp"))
+
+	policy = &OwnershipPolicy{
+		NamePrefixes: []string{"myapp"},
+		Patterns:     []*regexp.Regexp{regexp.MustCompile(`^legacy[-_]myapp$`)},
+		Allow:        []string{"shared-frontend"},
+		Deny:         []string{"myapp-shared", "shared-frontend"},
+		Applications: []string{"myapp"},
+	}
+	require.True(t, policy.Owns("myapp2", ""))
+	require.True(t, policy.Owns("legacy_myapp", ""))
+	require.True(t, policy.Owns("frontend", "myapp"))
+	require.False(t, policy.Owns("frontend", "otherapp"))
+	// deny takes precedence
+	require.False(t, policy.Owns("myapp-shared", "myapp"))
+	require.False(t, policy.Owns("shared-frontend", ""))
+}
+
+func TestExportableApplicationResourcesOwnership(t *testing.T) {
+	appData := &ApplicationResources{
+		AppName: "myapp",
+		LoadBalancers: []LoadBalancer{
+			{Name: "myapp-elb", Account: "test", Type: AWSCloudProvider},
+			{Name: "frontend", Account: "test", Type: AWSCloudProvider, Moniker: Moniker{App: "myapp"}},
+		},
+		SecurityGroups: []SecurityGroup{
+			{Name: "myapp", Account: "test"},
+			{Name: "legacy_myapp", Account: "test", Application: "legacy"},
+		},
+	}
+
+	exportable := ExportableApplicationResources(appData)
+	sort.Sort(ResourceSorter(exportable))
+	require.Equal(t, []*ExportableResource{
+		{ResourceType: LoadBalancerResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "frontend"},
+		{ResourceType: LoadBalancerResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp-elb"},
+		{ResourceType: SecurityGroupResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp"},
+	}, exportable)
+
+	appData.Ownership = DefaultOwnershipPolicy("myapp")
+	appData.Ownership.Applications = []string{"myapp", "legacy"}
+	appData.Ownership.Deny = []string{"myapp-elb"}
+	exportable = ExportableApplicationResources(appData)
+	sort.Sort(ResourceSorter(exportable))
+	require.Equal(t, []*ExportableResource{
+		{ResourceType: LoadBalancerResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "frontend"},
+		{ResourceType: SecurityGroupResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "legacy_myapp"},
+		{ResourceType: SecurityGroupResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "myapp"},
+	}, exportable)
+}
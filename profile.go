@@ -0,0 +1,22 @@
+package mdlib
+
+import "net/http"
+
+// Profile bundles the settings needed to reach a specific Spinnaker installation,
+// so commands can be pointed at test or prod Gate by name.
+type Profile struct {
+	Name string
+	// BaseURL is the base URL to reach the Spinnaker API.
+	BaseURL string
+	// HTTPClient is used to send authenticated requests, the Client default is
+	// used if nil.  TLS and proxy settings must be applied to it with
+	// ApplyTransportOptions since WithTransport cannot be combined with it.
+	HTTPClient func(*http.Request) (*http.Response, error)
+	// ClientOpts are additional options applied to the Client, such as
+	// authentication, retries or timeouts.
+	ClientOpts []ClientOpt
+	// ConfigDir and ConfigFile override the default delivery config location
+	// when set.
+	ConfigDir  string
+	ConfigFile string
+}
@@ -0,0 +1,173 @@
+package mdlib
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"golang.org/x/xerrors"
+)
+
+// CloudProvider describes how resources for a Spinnaker cloud provider are found,
+// exported and mapped to delivery config kinds.  Providers are added with
+// RegisterCloudProvider, the ec2, titus and kubernetes providers are registered by
+// default.
+type CloudProvider struct {
+	// Name is the Spinnaker cloud provider name, like `aws`, it is used for
+	// ExportableResource.CloudProvider and in the export APIs.
+	Name string
+	// KindPrefixes are the groups used in delivery config kinds for the provider,
+	// like `ec2` for `ec2/cluster@v1`.  Name is used when empty.
+	KindPrefixes []string
+	// ResourceTypes are the resource types that can be exported, like `cluster`.
+	ResourceTypes []string
+	// Experimental is set for providers whose exported resources have not been
+	// verified against the Spinnaker resource handlers, they should be reviewed
+	// before publishing.
+	Experimental bool
+	// ArtifactResourceTypes are the resource types that deploy an artifact, the
+	// artifact is exported along with the resource.
+	ArtifactResourceTypes []string
+	// Scanner returns the exportable resources for the provider found in the
+	// application resources.
+	Scanner func(*ApplicationResources) []*ExportableResource
+	// Exporter returns the delivery config YAML for the resource, the
+	// ManagedDeliveryAPI ExportResource is used when nil.
+	Exporter func(context.Context, ManagedDeliveryAPI, *ExportableResource) ([]byte, error)
+	// ArtifactExporter returns the artifact deployed by the resource, the
+	// ManagedDeliveryAPI ExportArtifact is used when nil.
+	ArtifactExporter func(context.Context, ManagedDeliveryAPI, *ExportableResource) (*DeliveryArtifact, error)
+	// ArtifactReferenceRewriter sets the artifact reference in the spec of an
+	// exported resource with kind, it is used when the reference is renamed to
+	// avoid a collision with an existing artifact.
+	ArtifactReferenceRewriter func(kind string, spec map[string]interface{}, reference string) error
+}
+
+// KindPrefix returns the group used for new delivery config kinds for the provider.
+func (p *CloudProvider) KindPrefix() string {
+	return p.kindPrefixes()[0]
+}
+
+// HasKindPrefix returns true if kind, like `ec2/cluster@v1`, belongs to the provider.
+func (p *CloudProvider) HasKindPrefix(kind string) bool {
+	return containsString(p.kindPrefixes(), strings.SplitN(kind, "/", 2)[0])
+}
+
+func (p *CloudProvider) kindPrefixes() []string {
+	if len(p.KindPrefixes) > 0 {
+		return p.KindPrefixes
+	}
+	return []string{p.Name}
+}
+
+// SupportsResourceType returns true if resources of resourceType can be exported.
+func (p *CloudProvider) SupportsResourceType(resourceType string) bool {
+	return containsString(p.ResourceTypes, resourceType)
+}
+
+// HasArtifact returns true if resources of resourceType deploy an artifact.
+func (p *CloudProvider) HasArtifact(resourceType string) bool {
+	return containsString(p.ArtifactResourceTypes, resourceType)
+}
+
+// ExportResource returns the delivery config YAML for the resource.
+func (p *CloudProvider) ExportResource(ctx context.Context, api ManagedDeliveryAPI, resource *ExportableResource) ([]byte, error) {
+	if p.Exporter != nil {
+		return p.Exporter(ctx, api, resource)
+	}
+	return api.ExportResource(ctx, resource)
+}
+
+// ExportArtifact returns the artifact deployed by the resource, or nil if resources of
+// that type do not deploy an artifact.
+func (p *CloudProvider) ExportArtifact(ctx context.Context, api ManagedDeliveryAPI, resource *ExportableResource) (*DeliveryArtifact, error) {
+	if !p.HasArtifact(resource.ResourceType) {
+		return nil, nil
+	}
+	if p.ArtifactExporter != nil {
+		return p.ArtifactExporter(ctx, api, resource)
+	}
+	return api.ExportArtifact(ctx, resource)
+}
+
+// UpdateArtifactReference sets the artifact reference in the spec of a resource with kind.
+func (p *CloudProvider) UpdateArtifactReference(kind string, spec map[string]interface{}, reference string) error {
+	if p.ArtifactReferenceRewriter == nil {
+		return xerrors.Errorf("cannot update artifact reference for unexpected kind: %q", kind)
+	}
+	return p.ArtifactReferenceRewriter(kind, spec, reference)
+}
+
+var cloudProviders = struct {
+	sync.RWMutex
+	providers []*CloudProvider
+}{
+	providers: []*CloudProvider{EC2Provider, TitusProvider, KubernetesProvider},
+}
+
+// RegisterCloudProvider adds the provider to the registry used to scan, export and map
+// kinds for resources, replacing any provider already registered with the same Name.
+func RegisterCloudProvider(provider *CloudProvider) {
+	cloudProviders.Lock()
+	defer cloudProviders.Unlock()
+	for i, p := range cloudProviders.providers {
+		if p.Name == provider.Name {
+			cloudProviders.providers[i] = provider
+			return
+		}
+	}
+	cloudProviders.providers = append(cloudProviders.providers, provider)
+}
+
+// UnregisterCloudProvider removes the provider registered with the Spinnaker cloud provider
+// name, returning false if there was none.
+func UnregisterCloudProvider(name string) bool {
+	cloudProviders.Lock()
+	defer cloudProviders.Unlock()
+	for i, p := range cloudProviders.providers {
+		if p.Name == name {
+			cloudProviders.providers = append(cloudProviders.providers[:i:i], cloudProviders.providers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RegisteredCloudProviders returns the registered providers in the order they were
+// registered.
+func RegisteredCloudProviders() []*CloudProvider {
+	cloudProviders.RLock()
+	defer cloudProviders.RUnlock()
+	return append([]*CloudProvider{}, cloudProviders.providers...)
+}
+
+// LookupCloudProvider returns the registered provider with the Spinnaker cloud provider
+// name, like `aws`.
+func LookupCloudProvider(name string) (*CloudProvider, bool) {
+	for _, p := range RegisteredCloudProviders() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// CloudProviderForKind returns the registered provider for the delivery config kind,
+// like `ec2/cluster@v1`.
+func CloudProviderForKind(kind string) (*CloudProvider, bool) {
+	for _, p := range RegisteredCloudProviders() {
+		if p.HasKindPrefix(kind) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
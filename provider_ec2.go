@@ -0,0 +1,88 @@
+package mdlib
+
+import (
+	"golang.org/x/xerrors"
+)
+
+// EC2Provider is the CloudProvider for AWS resources, which use `ec2` kinds.
+var EC2Provider = &CloudProvider{
+	Name:         AWSCloudProvider,
+	KindPrefixes: []string{"ec2"},
+	ResourceTypes: []string{
+		ClusterResourceType,
+		LoadBalancerResourceType,
+		ApplicationLoadBalancerResourceType,
+		NetworkLoadBalancerResourceType,
+		SecurityGroupResourceType,
+	},
+	ArtifactResourceTypes:     []string{ClusterResourceType},
+	Scanner:                   scanEC2Resources,
+	ArtifactReferenceRewriter: rewriteEC2ArtifactReference,
+}
+
+func scanEC2Resources(appData *ApplicationResources) []*ExportableResource {
+	exportable := scanClusters(appData, AWSCloudProvider)
+
+	for _, lb := range appData.LoadBalancers {
+		// only export things that belong to this app
+		if lb.Type == AWSCloudProvider && appData.Owns(lb.Name, lb.Moniker.App) {
+			exportable = append(exportable, &ExportableResource{
+				ResourceType:  lb.ResourceType(),
+				CloudProvider: AWSCloudProvider,
+				Account:       lb.Account,
+				Name:          lb.Name,
+			})
+		}
+	}
+
+	for _, sg := range appData.SecurityGroups {
+		if appData.Owns(sg.Name, sg.Application) {
+			exportable = append(exportable, &ExportableResource{
+				ResourceType:  SecurityGroupResourceType,
+				CloudProvider: AWSCloudProvider,
+				Account:       sg.Account,
+				Name:          sg.Name,
+			})
+		}
+	}
+	return exportable
+}
+
+// scanClusters returns a cluster resource for each server group from the provider.
+func scanClusters(appData *ApplicationResources, provider string) []*ExportableResource {
+	exportable := []*ExportableResource{}
+	for _, asg := range appData.ServerGroups {
+		if asg.Type == provider {
+			exportable = append(exportable, &ExportableResource{
+				ResourceType:  ClusterResourceType,
+				CloudProvider: provider,
+				Account:       asg.Account,
+				Name:          asg.Moniker.Cluster,
+			})
+		}
+	}
+	return exportable
+}
+
+func rewriteEC2ArtifactReference(kind string, spec map[string]interface{}, reference string) error {
+	switch kind {
+	case "ec2/cluster@v1":
+		// kind: ec2/cluster@v1
+		// spec:
+		//   imageProvider:
+		//     reference: some-deb
+		imageProvider, ok := spec["imageProvider"].(map[string]interface{})
+		if !ok {
+			return xerrors.New("resource for ec2/cluster@v1 missing spec.imageProvider property")
+		}
+		imageProvider["reference"] = reference
+	case "ec2/cluster@v1.1":
+		// kind: ec2/cluster@v1.1
+		// spec:
+		//   artifactReference: some-deb
+		spec["artifactReference"] = reference
+	default:
+		return xerrors.Errorf("cannot update artifact reference for unexpected kind: %q", kind)
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package mdlib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+)
+
+// kubernetesWorkloadTypes are the kubernetes kinds that run containers, the container
+// image is exported as a docker artifact.
+var kubernetesWorkloadTypes = []string{"deployment", "statefulSet", "daemonSet"}
+
+// KubernetesProvider is the CloudProvider for kubernetes manifests deployed by the
+// Spinnaker kubernetes provider, which use `k8s` kinds like `k8s/deployment@v1`.  The
+// resource type is the kubernetes kind as used by Spinnaker, like `deployment`, and the
+// resources are exported from the manifests since there is no Spinnaker export API for
+// them.
+//
+// The provider is experimental, the exported kinds and the spec.container.reference
+// property have not been verified against the Spinnaker kubernetes resource handler.
+var KubernetesProvider = &CloudProvider{
+	Name:                      KubernetesCloudProvider,
+	KindPrefixes:              []string{"k8s"},
+	ResourceTypes:             append([]string{"service"}, kubernetesWorkloadTypes...),
+	Experimental:              true,
+	ArtifactResourceTypes:     kubernetesWorkloadTypes,
+	Scanner:                   scanKubernetesResources,
+	Exporter:                  exportKubernetesResource,
+	ArtifactExporter:          exportKubernetesArtifact,
+	ArtifactReferenceRewriter: rewriteKubernetesArtifactReference,
+}
+
+func scanKubernetesResources(appData *ApplicationResources) []*ExportableResource {
+	exportable := []*ExportableResource{}
+	for _, sg := range appData.ServerGroups {
+		if sg.Type != KubernetesCloudProvider {
+			continue
+		}
+		// server groups are replicaSets, statefulSets and daemonSets, the cluster is
+		// the manifest that manages them, like `deployment myapp`
+		if kind, name, ok := splitKubernetesName(sg.Moniker.Cluster); ok {
+			exportable = append(exportable, &ExportableResource{
+				ResourceType:  kind,
+				CloudProvider: KubernetesCloudProvider,
+				Account:       sg.Account,
+				Name:          name,
+				Namespace:     sg.Region,
+			})
+		}
+	}
+	for _, lb := range appData.LoadBalancers {
+		if lb.Type != KubernetesCloudProvider {
+			continue
+		}
+		// load balancers are services, like `service myapp`, only export the
+		// services that belong to this app
+		if kind, name, ok := splitKubernetesName(lb.Name); ok && appData.Owns(name, lb.Moniker.App) {
+			exportable = append(exportable, &ExportableResource{
+				ResourceType:  kind,
+				CloudProvider: KubernetesCloudProvider,
+				Account:       lb.Account,
+				Name:          name,
+				Namespace:     lb.Region,
+			})
+		}
+	}
+	return exportable
+}
+
+// splitKubernetesName splits a Spinnaker kubernetes name, like `deployment myapp`, into
+// the kind and manifest name.
+func splitKubernetesName(name string) (kind, manifestName string, ok bool) {
+	parts := strings.SplitN(name, " ", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func getKubernetesManifest(ctx context.Context, api ManagedDeliveryAPI, resource *ExportableResource) (*Manifest, error) {
+	manifestAPI, ok := api.(ManifestAPI)
+	if !ok {
+		return nil, xerrors.Errorf("failed to get manifest for %s: %T does not implement ManifestAPI", resource, api)
+	}
+	manifest, err := manifestAPI.Manifest(ctx, resource.Account, resource.Namespace, fmt.Sprintf("%s %s", resource.ResourceType, resource.Name))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to get manifest for %s: %w", resource, err)
+	}
+	return manifest, nil
+}
+
+func exportKubernetesResource(ctx context.Context, api ManagedDeliveryAPI, resource *ExportableResource) ([]byte, error) {
+	manifest, err := getKubernetesManifest(ctx, api, resource)
+	if err != nil {
+		return nil, err
+	}
+	moniker, err := kubernetesMoniker(manifest.Moniker.App, resource.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// kind: k8s/deployment@v1
+	// spec:
+	//   moniker:
+	//     app: myapp
+	//   locations:
+	//     account: k8s-test
+	//     namespace: default
+	//   container:
+	//     reference: myorg/myapp
+	//   template: <manifest>
+	spec := map[string]interface{}{
+		"moniker": moniker,
+		"locations": map[string]interface{}{
+			"account":   resource.Account,
+			"namespace": resource.Namespace,
+		},
+		"template": cleanKubernetesManifest(manifest.Manifest),
+	}
+	if containsString(kubernetesWorkloadTypes, resource.ResourceType) {
+		image, err := kubernetesImage(manifest)
+		if err != nil {
+			return nil, err
+		}
+		spec["container"] = map[string]interface{}{
+			"reference": dockerImageArtifact(image).RefName(),
+		}
+	}
+	return yaml.Marshal(map[string]interface{}{
+		"kind": fmt.Sprintf("k8s/%s@v1", resource.ResourceType),
+		"spec": spec,
+	})
+}
+
+func exportKubernetesArtifact(ctx context.Context, api ManagedDeliveryAPI, resource *ExportableResource) (*DeliveryArtifact, error) {
+	manifest, err := getKubernetesManifest(ctx, api, resource)
+	if err != nil {
+		return nil, err
+	}
+	image, err := kubernetesImage(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return dockerImageArtifact(image), nil
+}
+
+// kubernetesMoniker returns the moniker for a manifest named like `app-stack-detail`,
+// names that cannot be expressed as a moniker for app are an error since the delivery
+// config resource could not be matched to the manifest.
+func kubernetesMoniker(app, name string) (map[string]interface{}, error) {
+	if app == "" {
+		return nil, xerrors.Errorf("manifest %s is missing the spinnaker application moniker", name)
+	}
+	m := Moniker{App: app}
+	if name != app {
+		parts := strings.SplitN(strings.TrimPrefix(name, app+"-"), "-", 2)
+		m.Stack = parts[0]
+		if len(parts) > 1 {
+			m.Detail = parts[1]
+		}
+	}
+	if m.String() != name {
+		return nil, xerrors.Errorf("manifest name %q must be like %s-stack-detail to be exported", name, app)
+	}
+	moniker := map[string]interface{}{"app": m.App}
+	if m.Stack != "" {
+		moniker["stack"] = m.Stack
+	}
+	if m.Detail != "" {
+		moniker["detail"] = m.Detail
+	}
+	return moniker, nil
+}
+
+// kubernetesServerManagedMetadata are the manifest metadata properties set by kubernetes,
+// they are removed from the exported template.
+var kubernetesServerManagedMetadata = []string{
+	"creationTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// kubernetesServerManagedAnnotations are the annotation prefixes set by kubernetes and
+// Spinnaker when the manifest is deployed, they are removed from the exported template.
+var kubernetesServerManagedAnnotations = []string{
+	"artifact.spinnaker.io/",
+	"deployment.kubernetes.io/",
+	"kubectl.kubernetes.io/last-applied-configuration",
+	"moniker.spinnaker.io/",
+}
+
+// cleanKubernetesManifest removes the status and the metadata set when the manifest was
+// deployed so only the desired state is left.
+func cleanKubernetesManifest(manifest map[string]interface{}) map[string]interface{} {
+	delete(manifest, "status")
+	metadata, ok := manifest["metadata"].(map[string]interface{})
+	if !ok {
+		return manifest
+	}
+	for _, key := range kubernetesServerManagedMetadata {
+		delete(metadata, key)
+	}
+	if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+		for key := range annotations {
+			for _, prefix := range kubernetesServerManagedAnnotations {
+				if strings.HasPrefix(key, prefix) {
+					delete(annotations, key)
+				}
+			}
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+	return manifest
+}
+
+// kubernetesImage returns the first container image bound to the manifest, like
+// `registry.example.com/myorg/myapp:1.0.0`.
+func kubernetesImage(manifest *Manifest) (string, error) {
+	for _, artifact := range manifest.Artifacts {
+		if artifact.Type == "docker/image" && artifact.Reference != "" {
+			return artifact.Reference, nil
+		}
+	}
+	return "", xerrors.Errorf("no container image found for manifest %s", manifest.Name)
+}
+
+// dockerImageArtifact returns the docker artifact for image, the registry, tag and
+// digest are not part of the artifact name.
+func dockerImageArtifact(image string) *DeliveryArtifact {
+	if ix := strings.Index(image, "@"); ix >= 0 {
+		image = image[:ix]
+	}
+	tag := ""
+	if ix := strings.LastIndex(image, ":"); ix > strings.LastIndex(image, "/") {
+		image, tag = image[:ix], image[ix+1:]
+	}
+	// the registry is the first part when it looks like a host, like
+	// `registry.example.com/myorg/myapp` or `localhost:5000/myapp`
+	if parts := strings.SplitN(image, "/", 2); len(parts) == 2 {
+		if strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost" {
+			image = parts[1]
+		}
+	}
+	strategy := "semver-tag"
+	if tag != "" && strings.Trim(tag, "0123456789") == "" {
+		strategy = "increasing-tag"
+	}
+	return &DeliveryArtifact{
+		Name:               image,
+		Type:               DockerArtifactType,
+		Reference:          image,
+		TagVersionStrategy: strategy,
+	}
+}
+
+func rewriteKubernetesArtifactReference(kind string, spec map[string]interface{}, reference string) error {
+	// kind: k8s/deployment@v1
+	// spec:
+	//   container:
+	//     reference: myorg/myapp
+	container, ok := spec["container"].(map[string]interface{})
+	if !ok {
+		return xerrors.Errorf("resource for %s missing spec.container property", kind)
+	}
+	container["reference"] = reference
+	return nil
+}
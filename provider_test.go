@@ -0,0 +1,220 @@
+package mdlib
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudProviderRegistry(t *testing.T) {
+	provider, ok := CloudProviderForKind("ec2/cluster@v1")
+	require.True(t, ok)
+	require.Equal(t, EC2Provider, provider)
+	require.Equal(t, "ec2", provider.KindPrefix())
+
+	provider, ok = LookupCloudProvider(TitusCloudProvider)
+	require.True(t, ok)
+	require.Equal(t, "titus", provider.KindPrefix())
+	require.True(t, provider.HasKindPrefix("titus/cluster@v1"))
+
+	_, ok = CloudProviderForKind("lambda/function@v1")
+	require.False(t, ok)
+
+	// only the scanner, kind prefix and rewriter are needed to add a provider
+	t.Cleanup(func() {
+		UnregisterCloudProvider("aws-lambda")
+	})
+	RegisterCloudProvider(&CloudProvider{
+		Name:          "aws-lambda",
+		KindPrefixes:  []string{"lambda"},
+		ResourceTypes: []string{"function"},
+		Scanner: func(appData *ApplicationResources) []*ExportableResource {
+			if appData.AppName != "lambdaapp" {
+				return nil
+			}
+			return []*ExportableResource{
+				{ResourceType: "function", CloudProvider: "aws-lambda", Account: "test", Name: "lambdaapp"},
+				// not in ResourceTypes so it is ignored
+				{ResourceType: "layer", CloudProvider: "aws-lambda", Account: "test", Name: "lambdaapp"},
+			}
+		},
+		ArtifactReferenceRewriter: func(kind string, spec map[string]interface{}, reference string) error {
+			spec["artifactReference"] = reference
+			return nil
+		},
+	})
+
+	exportable := ExportableApplicationResources(&ApplicationResources{
+		AppName: "lambdaapp",
+		ServerGroups: []ServerGroup{
+			{Account: "test", Type: AWSCloudProvider, Moniker: Moniker{App: "lambdaapp", Cluster: "lambdaapp"}},
+			// no provider is registered for gce but the cluster is still exportable
+			{Account: "gcetest", Type: "gce", Moniker: Moniker{App: "lambdaapp", Cluster: "lambdaapp"}},
+		},
+	})
+	sort.Sort(ResourceSorter(exportable))
+	require.Equal(t, []*ExportableResource{
+		{ResourceType: ClusterResourceType, CloudProvider: AWSCloudProvider, Account: "test", Name: "lambdaapp"},
+		{ResourceType: ClusterResourceType, CloudProvider: "gce", Account: "gcetest", Name: "lambdaapp"},
+		{ResourceType: "function", CloudProvider: "aws-lambda", Account: "test", Name: "lambdaapp"},
+	}, exportable)
+
+	resource := DeliveryResource{Kind: "lambda/function@v1"}
+	require.Equal(t, "aws-lambda", resource.CloudProvider())
+	require.True(t, exportable[2].HasKind(resource.Kind))
+	require.False(t, exportable[0].HasKind(resource.Kind))
+
+	p := NewDeliveryConfigProcessor()
+	content := []byte("kind: lambda/function@v1\nspec:\n  artifactReference: lambdaapp\n")
+	require.NoError(t, p.UpdateArtifactReference(&content, "lambdaapp-2"))
+	require.Equal(t, "kind: lambda/function@v1\nspec:\n    artifactReference: lambdaapp-2\n", string(content))
+
+	require.True(t, UnregisterCloudProvider("aws-lambda"))
+	require.False(t, UnregisterCloudProvider("aws-lambda"))
+	_, ok = CloudProviderForKind("lambda/function@v1")
+	require.False(t, ok)
+	require.Len(t, RegisteredCloudProviders(), 3)
+}
+
+func TestUpdateArtifactReference(t *testing.T) {
+	p := NewDeliveryConfigProcessor()
+	for kind, doc := range map[string]string{
+		"ec2/cluster@v1":   "kind: ec2/cluster@v1\nspec:\n  imageProvider:\n    reference: myapp\n",
+		"ec2/cluster@v1.1": "kind: ec2/cluster@v1.1\nspec:\n  artifactReference: myapp\n",
+		"titus/cluster@v1": "kind: titus/cluster@v1\nspec:\n  container:\n    reference: myorg/myapp\n",
+	} {
+		content := []byte(doc)
+		require.NoError(t, p.UpdateArtifactReference(&content, "updated"), kind)
+		require.Contains(t, string(content), "updated", kind)
+	}
+
+	content := []byte("kind: ec2/security-group@v1\nspec: {}\n")
+	require.Error(t, p.UpdateArtifactReference(&content, "updated"))
+
+	content = []byte("kind: unknown/cluster@v1\nspec: {}\n")
+	require.Error(t, p.UpdateArtifactReference(&content, "updated"))
+}
+
+func TestKubernetesProvider(t *testing.T) {
+	api := &fakeAPI{
+		serverGroups: []ServerGroup{
+			{Name: "replicaSet myapp-web-v003", Account: "k8s-test", Region: "default", Type: KubernetesCloudProvider, Moniker: Moniker{App: "myapp", Cluster: "deployment myapp-web"}},
+			{Name: "replicaSet myapp-v001", Account: "k8s-test", Region: "default", Type: KubernetesCloudProvider, Moniker: Moniker{App: "myapp", Cluster: "replicaSet myapp"}},
+		},
+		loadBalancers: []LoadBalancer{
+			{Name: "service myapp-web", Account: "k8s-test", Region: "default", Type: KubernetesCloudProvider},
+			// shared services are only exported when owned by the application
+			{Name: "service ingress", Account: "k8s-test", Region: "default", Type: KubernetesCloudProvider, Moniker: Moniker{App: "platform"}},
+		},
+		manifests: map[string]*Manifest{
+			"k8s-test/default/deployment myapp-web": {
+				Account:  "k8s-test",
+				Location: "default",
+				Name:     "deployment myapp-web",
+				Moniker:  Moniker{App: "myapp", Cluster: "deployment myapp-web"},
+				Manifest: map[string]interface{}{
+					"apiVersion": "apps/v1",
+					"kind":       "Deployment",
+					"metadata": map[string]interface{}{
+						"name":            "myapp-web",
+						"namespace":       "default",
+						"uid":             "8c7d5e0a",
+						"resourceVersion": "1234",
+						"annotations": map[string]interface{}{
+							"artifact.spinnaker.io/name":        "registry.example.com/myorg/myapp",
+							"deployment.kubernetes.io/revision": "3",
+							"moniker.spinnaker.io/application":  "myapp",
+						},
+					},
+					"spec": map[string]interface{}{
+						"replicas": 2,
+					},
+					"status": map[string]interface{}{
+						"readyReplicas": 2,
+					},
+				},
+				Artifacts: []ManifestArtifact{
+					{Type: "docker/image", Name: "registry.example.com/myorg/myapp", Reference: "registry.example.com/myorg/myapp:1.2.3", Version: "1.2.3"},
+				},
+			},
+		},
+	}
+
+	appData, err := FindApplicationResourcesFromAPI(context.Background(), api, "myapp")
+	require.NoError(t, err)
+
+	exportable := ExportableApplicationResources(appData)
+	sort.Sort(ResourceSorter(exportable))
+	// the replicaSet is not managed by a deployment so it is not exportable
+	require.Equal(t, []*ExportableResource{
+		{ResourceType: "deployment", CloudProvider: KubernetesCloudProvider, Account: "k8s-test", Name: "myapp-web", Namespace: "default"},
+		{ResourceType: "service", CloudProvider: KubernetesCloudProvider, Account: "k8s-test", Name: "myapp-web", Namespace: "default"},
+	}, exportable)
+	require.Equal(t, "deployment myapp-web [kubernetes/k8s-test/default]", exportable[0].String())
+
+	content, err := KubernetesProvider.ExportResource(context.Background(), api, exportable[0])
+	require.NoError(t, err)
+	require.Equal(t, `kind: k8s/deployment@v1
+spec:
+    container:
+        reference: myorg/myapp
+    locations:
+        account: k8s-test
+        namespace: default
+    moniker:
+        app: myapp
+        stack: web
+    template:
+        apiVersion: apps/v1
+        kind: Deployment
+        metadata:
+            name: myapp-web
+            namespace: default
+        spec:
+            replicas: 2
+`, string(content))
+
+	artifact, err := KubernetesProvider.ExportArtifact(context.Background(), api, exportable[0])
+	require.NoError(t, err)
+	require.Equal(t, &DeliveryArtifact{
+		Name:               "myorg/myapp",
+		Type:               DockerArtifactType,
+		Reference:          "myorg/myapp",
+		TagVersionStrategy: "semver-tag",
+	}, artifact)
+
+	// the exported resource is found again in the delivery config
+	p := NewDeliveryConfigProcessor(WithDirectory(t.TempDir()))
+	require.NoError(t, p.Load())
+	added, err := p.UpsertResource(exportable[0], "testing", content)
+	require.NoError(t, err)
+	require.True(t, added)
+	require.True(t, p.ResourceExists(exportable[0]))
+	require.False(t, p.ResourceExists(&ExportableResource{ResourceType: "deployment", CloudProvider: KubernetesCloudProvider, Account: "k8s-test", Name: "myapp-web", Namespace: "other"}))
+
+	require.NoError(t, p.UpdateArtifactReference(&content, "myorg/myapp-2"))
+	require.Contains(t, string(content), "reference: myorg/myapp-2")
+
+	_, err = KubernetesProvider.ExportResource(context.Background(), api, exportable[1])
+	require.Error(t, err)
+
+	// manifests are only available from a ManagedDeliveryAPI that implements ManifestAPI
+	_, err = KubernetesProvider.ExportResource(context.Background(), struct{ ManagedDeliveryAPI }{api}, exportable[0])
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "does not implement ManifestAPI")
+}
+
+func TestDockerImageArtifact(t *testing.T) {
+	for image, expected := range map[string][2]string{
+		"myapp":                               {"myapp", "semver-tag"},
+		"myorg/myapp:v1.0.0":                  {"myorg/myapp", "semver-tag"},
+		"registry.example.com/myorg/myapp:42": {"myorg/myapp", "increasing-tag"},
+		"localhost:5000/myapp@sha256:0123456789abcd": {"myapp", "semver-tag"},
+	} {
+		artifact := dockerImageArtifact(image)
+		require.Equal(t, expected[0], artifact.Name, image)
+		require.Equal(t, expected[1], artifact.TagVersionStrategy, image)
+	}
+}
@@ -0,0 +1,34 @@
+package mdlib
+
+import (
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// TitusProvider is the CloudProvider for Titus container resources.
+var TitusProvider = &CloudProvider{
+	Name:                  TitusCloudProvider,
+	ResourceTypes:         []string{ClusterResourceType},
+	ArtifactResourceTypes: []string{ClusterResourceType},
+	Scanner: func(appData *ApplicationResources) []*ExportableResource {
+		return scanClusters(appData, TitusCloudProvider)
+	},
+	ArtifactReferenceRewriter: rewriteTitusArtifactReference,
+}
+
+func rewriteTitusArtifactReference(kind string, spec map[string]interface{}, reference string) error {
+	if !strings.HasPrefix(kind, "titus/cluster@v1") {
+		return xerrors.Errorf("cannot update artifact reference for unexpected kind: %q", kind)
+	}
+	// kind: titus/cluster@v1
+	// spec:
+	//   container:
+	//     reference: some/image
+	container, ok := spec["container"].(map[string]interface{})
+	if !ok {
+		return xerrors.New("resource for titus/cluster@v1 missing spec.container property")
+	}
+	container["reference"] = reference
+	return nil
+}
@@ -0,0 +1,116 @@
+package mdlib
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests to the Spinnaker API are retried.
+// Only requests that are safe to repeat are retried: GET, HEAD and OPTIONS
+// requests, along with POST requests that are known to be side-effect free
+// (ie diff, validate and actuation plan requests).
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts made for a request,
+	// including the first.  Values less than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry, it is doubled for
+	// each subsequent retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, including delays requested
+	// by a Retry-After response header.
+	MaxBackoff time.Duration
+	// RetryableStatusCodes are the response codes that will trigger a retry.
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy is a reasonable RetryPolicy for riding out Gate restarts
+// and rate limiting.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     10 * time.Second,
+	RetryableStatusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// WithRetryPolicy is a ClientOpt to set the RetryPolicy via NewClient.  By
+// default requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) ClientOpt {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// canRetry returns true if the request may be safely sent again.
+func (p RetryPolicy) canRetry(method string, body requestBody) bool {
+	if p.MaxAttempts < 2 {
+		return false
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return body.SideEffectFree
+}
+
+// retryableStatus returns true if the response status code should be retried.
+func (p RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the delay before the next attempt, attempt is 1 for the
+// first retry.  The delay is exponential with jitter, unless the server
+// requested a specific delay via the Retry-After header.
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxBackoff > 0 && d > p.MaxBackoff {
+				return p.MaxBackoff
+			}
+			return d
+		}
+	}
+	d := p.InitialBackoff << (attempt - 1)
+	if d <= 0 || (p.MaxBackoff > 0 && d > p.MaxBackoff) {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	// pick a random delay in [d/2, d) so concurrent clients spread out
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// parseRetryAfter parses a Retry-After header value which can be either
+// a number of seconds or an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
@@ -1,6 +1,14 @@
 package mdlib
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"golang.org/x/xerrors"
+)
 
 // SecurityGroup contains the relevant detail for mapping a SG id to a SG name.
 type SecurityGroup struct {
@@ -8,6 +16,8 @@ type SecurityGroup struct {
 	ID      string `json:"id"`
 	Region  string `json:"region"`
 	Account string `json:"account"`
+	// Application is the Spinnaker application inferred from the name
+	Application string `json:"application"`
 }
 
 // // Region is alias to string to make SecurityGroups map more clear
@@ -16,15 +26,137 @@ type SecurityGroup struct {
 // // SecurityGroups is a map from region to security groups
 // type SecurityGroups map[Region][]SecurityGroup
 
+// DefaultSearchPageSize is the number of results requested per page when searching, like for
+// security groups, unless changed with WithSearchPageSize.
+const DefaultSearchPageSize = 500
+
+// WithSearchPageSize is a ClientOpt to set the number of results requested per page when
+// searching, like for security groups.  Sizes less than 1 use DefaultSearchPageSize.
+func WithSearchPageSize(pageSize int) ClientOpt {
+	return func(c *Client) {
+		c.searchPageSize = pageSize
+	}
+}
+
+// SecurityGroupFilter limits the security groups returned from SearchSecurityGroups.  Empty
+// fields will match all security groups.
+type SecurityGroupFilter struct {
+	Account string
+	Region  string
+}
+
+// Match returns true if the security group matches the filter.
+func (f SecurityGroupFilter) Match(sg SecurityGroup) bool {
+	if f.Account != "" && f.Account != sg.Account {
+		return false
+	}
+	if f.Region != "" && f.Region != sg.Region {
+		return false
+	}
+	return true
+}
+
 // GetSecurityGroups populates the security groups result structure for spinnaker account provided.
 // Unless a custom result type is required, *[]SecurityGroup is recommended.
 func GetSecurityGroups(cli *Client, appName string, result interface{}) error {
-	data := &[]struct {
-		Results interface{} `json:"results"`
-	}{{
-		Results: result,
-	}}
-	return commonParsedGet(cli, fmt.Sprintf("/search?pageSize=500&q=%s&type=securityGroups", appName), &data)
+	return GetSecurityGroupsContext(context.Background(), cli, appName, result)
+}
+
+// GetSecurityGroupsContext is like GetSecurityGroups but the request is bound to ctx.
+func GetSecurityGroupsContext(ctx context.Context, cli *Client, appName string, result interface{}) error {
+	results := []json.RawMessage{}
+	err := searchPages(ctx, cli, appName, "securityGroups", func(r json.RawMessage) error {
+		results = append(results, r)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// round trip through json so we can populate custom result types
+	content, err := json.Marshal(results)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal security group search results: %w", err)
+	}
+	err = json.Unmarshal(content, result)
+	if err != nil {
+		return xerrors.Errorf(
+			"failed to parse security group search results: %w",
+			ErrorInvalidContent{Content: content, ParseError: err},
+		)
+	}
+	return nil
+}
+
+// SearchSecurityGroups will page through all the security groups for the spinnaker application appName
+// and call fn for each security group that matches the filter.  Results are not held in memory so this
+// is suitable for applications with very many security groups.  Searching will stop if fn returns an error.
+func SearchSecurityGroups(cli *Client, appName string, filter SecurityGroupFilter, fn func(SecurityGroup) error) error {
+	return SearchSecurityGroupsContext(context.Background(), cli, appName, filter, fn)
+}
+
+// SearchSecurityGroupsContext is like SearchSecurityGroups but the requests are bound to ctx.
+func SearchSecurityGroupsContext(ctx context.Context, cli *Client, appName string, filter SecurityGroupFilter, fn func(SecurityGroup) error) error {
+	return searchPages(ctx, cli, appName, "securityGroups", func(r json.RawMessage) error {
+		sg := SecurityGroup{}
+		err := json.Unmarshal(r, &sg)
+		if err != nil {
+			return xerrors.Errorf(
+				"failed to parse security group search result: %w",
+				ErrorInvalidContent{Content: r, ParseError: err},
+			)
+		}
+		if !filter.Match(sg) {
+			return nil
+		}
+		return fn(sg)
+	})
+}
+
+// searchResults is a single page of results from the /search api
+type searchResults struct {
+	TotalMatches int               `json:"totalMatches"`
+	PageNumber   int               `json:"pageNumber"`
+	PageSize     int               `json:"pageSize"`
+	Results      []json.RawMessage `json:"results"`
+}
+
+// searchPages will request pages from the /search api until all results have been
+// seen, calling fn for each result.
+func searchPages(ctx context.Context, cli *Client, query, resultType string, fn func(json.RawMessage) error) error {
+	pageSize := cli.searchPageSize
+	if pageSize < 1 {
+		pageSize = DefaultSearchPageSize
+	}
+	seen := 0
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("q", query)
+		params.Set("type", resultType)
+		params.Set("pageSize", strconv.Itoa(pageSize))
+		params.Set("page", strconv.Itoa(page))
+
+		data := []searchResults{}
+		err := commonParsedGet(ctx, cli, "/search?"+params.Encode(), &data)
+		if err != nil {
+			return err
+		}
+
+		count, total := 0, 0
+		for _, platform := range data {
+			total += platform.TotalMatches
+			for _, r := range platform.Results {
+				count++
+				if err := fn(r); err != nil {
+					return err
+				}
+			}
+		}
+		seen += count
+
+		if count == 0 || count < pageSize || (total > 0 && seen >= total) {
+			return nil
+		}
+	}
 }
 
 // Credential contains account status
@@ -37,7 +169,10 @@ type Credential struct {
 // GetCredential populates the credential result structure for the spinnaker account provided.
 // Unless a custom result type is required, *Credential is recommended
 func GetCredential(cli *Client, account string, result interface{}) error {
-	return commonParsedGet(cli, fmt.Sprintf("/credentials/%s", account), result)
+	return GetCredentialContext(context.Background(), cli, account, result)
 }
 
-// func SearchSecurityGroups(cli *Client)
+// GetCredentialContext is like GetCredential but the request is bound to ctx.
+func GetCredentialContext(ctx context.Context, cli *Client, account string, result interface{}) error {
+	return commonParsedGet(ctx, cli, fmt.Sprintf("/credentials/%s", account), result)
+}
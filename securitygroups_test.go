@@ -0,0 +1,97 @@
+package mdlib
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchSecurityGroupsPaging(t *testing.T) {
+	all := []SecurityGroup{
+		{Name: "myapp", Account: "test", Region: "us-east-1"},
+		{Name: "myapp", Account: "test", Region: "us-west-2"},
+		{Name: "myapp-rds", Account: "dbs", Region: "us-west-2"},
+		{Name: "myapp-elb", Account: "test", Region: "us-east-1"},
+		{Name: "myapp-db", Account: "prod", Region: "us-east-1"},
+	}
+
+	pages := []int{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/search", r.URL.Path)
+				require.Equal(t, "myapp", r.URL.Query().Get("q"))
+				require.Equal(t, "securityGroups", r.URL.Query().Get("type"))
+				page, err := strconv.Atoi(r.URL.Query().Get("page"))
+				require.NoError(t, err)
+				pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+				require.NoError(t, err)
+				pages = append(pages, page)
+
+				start := (page - 1) * pageSize
+				end := start + pageSize
+				if end > len(all) {
+					end = len(all)
+				}
+				results := []SecurityGroup{}
+				if start < len(all) {
+					results = all[start:end]
+				}
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode([]map[string]interface{}{{
+					"totalMatches": len(all),
+					"pageNumber":   page,
+					"pageSize":     pageSize,
+					"results":      results,
+				}})
+			},
+		),
+	)
+	defer ts.Close()
+
+	cli := NewClient(WithBaseURL(ts.URL), WithSearchPageSize(2))
+
+	sgs := []SecurityGroup{}
+	err := GetSecurityGroups(cli, "myapp", &sgs)
+	require.NoError(t, err)
+	require.Equal(t, all, sgs)
+	require.Equal(t, []int{1, 2, 3}, pages)
+
+	pages = nil
+	filtered := []SecurityGroup{}
+	err = SearchSecurityGroups(cli, "myapp", SecurityGroupFilter{Account: "test", Region: "us-east-1"}, func(sg SecurityGroup) error {
+		filtered = append(filtered, sg)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []SecurityGroup{all[0], all[3]}, filtered)
+	require.Equal(t, []int{1, 2, 3}, pages)
+}
+
+func TestSearchSecurityGroupsPageSize(t *testing.T) {
+	pageSizes := []string{}
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				pageSizes = append(pageSizes, r.URL.Query().Get("pageSize"))
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"totalMatches": 0, "pageNumber": 1, "results": []}]`))
+			},
+		),
+	)
+	defer ts.Close()
+
+	// non-positive page sizes use the default, an empty page ends the search
+	for _, pageSize := range []int{0, -1} {
+		pageSizes = nil
+		cli := NewClient(WithBaseURL(ts.URL), WithSearchPageSize(pageSize))
+		sgs := []SecurityGroup{}
+		require.NoError(t, GetSecurityGroups(cli, "myapp", &sgs))
+		require.Empty(t, sgs)
+		require.Equal(t, []string{strconv.Itoa(DefaultSearchPageSize)}, pageSizes)
+	}
+}
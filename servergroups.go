@@ -1,6 +1,7 @@
 package mdlib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -89,5 +90,10 @@ func (m Moniker) String() string {
 // GetServerGroups populates the server groups result structure for spinnaker application appName.
 // Unless a custom result type is required, *[]ServerGroup is recommended.
 func GetServerGroups(cli *Client, appName string, result interface{}) error {
-	return commonParsedGet(cli, fmt.Sprintf("/applications/%s/serverGroups", appName), result)
+	return GetServerGroupsContext(context.Background(), cli, appName, result)
+}
+
+// GetServerGroupsContext is like GetServerGroups but the request is bound to ctx.
+func GetServerGroupsContext(ctx context.Context, cli *Client, appName string, result interface{}) error {
+	return commonParsedGet(ctx, cli, fmt.Sprintf("/applications/%s/serverGroups", appName), result)
 }
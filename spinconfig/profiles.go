@@ -0,0 +1,186 @@
+package spinconfig
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/homedir"
+)
+
+// ProfileEnvVar is the environment variable used to select a profile when one
+// is not requested explicitly.
+const ProfileEnvVar = "SPINMD_PROFILE"
+
+// DefaultProfilesPath returns the location of the spinmd profiles file, ~/.spin/profiles.yml
+func DefaultProfilesPath() string {
+	return filepath.Join(homedir.HomeDir(), ".spin", "profiles.yml")
+}
+
+// ProfileConfig is the configuration for a single Spinnaker installation in the
+// profiles file.
+type ProfileConfig struct {
+	// BaseURL overrides the gate endpoint from the spin config, if neither is set
+	// the SPINNAKER_API_BASE_URL environment variable is used.
+	BaseURL string `yaml:"baseUrl,omitempty"`
+	// SpinConfig is the path to the spin CLI config with the auth settings for this
+	// installation, defaults to ~/.spin/config.
+	SpinConfig string `yaml:"spinConfig,omitempty"`
+	// ConfigDir and ConfigFile are the default delivery config location.
+	ConfigDir  string `yaml:"dir,omitempty"`
+	ConfigFile string `yaml:"file,omitempty"`
+
+	CAFile   string        `yaml:"caFile,omitempty"`
+	CertFile string        `yaml:"certFile,omitempty"`
+	KeyFile  string        `yaml:"keyFile,omitempty"`
+	Insecure bool          `yaml:"insecure,omitempty"`
+	Proxy    string        `yaml:"proxy,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Profiles is the content of the profiles file, for example:
+//
+//	default: test
+//	profiles:
+//	  test:
+//	    baseUrl: https://gate.test.example.com
+//	  prod:
+//	    spinConfig: ~/.spin/prod-config
+//	    timeout: 30s
+type Profiles struct {
+	Default  string                    `yaml:"default,omitempty"`
+	Profiles map[string]*ProfileConfig `yaml:"profiles,omitempty"`
+}
+
+// LoadProfiles will read the profiles from profilesFile.  Environment variables
+// in the file are expanded.  Empty profiles are returned if the file does not
+// exist.
+func LoadProfiles(profilesFile string) (*Profiles, error) {
+	profiles := &Profiles{}
+	content, err := ioutil.ReadFile(profilesFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, xerrors.Errorf("unable to read %s: %w", profilesFile, err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(os.ExpandEnv(string(content)))))
+	dec.KnownFields(true)
+	err = dec.Decode(profiles)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse %s as YAML: %w", profilesFile, err)
+	}
+	return profiles, nil
+}
+
+// Lookup returns the profile called name.  If name is empty the profile named by
+// the ProfileEnvVar environment variable is used, then the default profile.  If
+// no profile is selected at all an empty ProfileConfig is returned so the spin
+// CLI config is used as is.
+func (p *Profiles) Lookup(name string) (string, *ProfileConfig, error) {
+	if name == "" {
+		name = os.Getenv(ProfileEnvVar)
+	}
+	if name == "" {
+		name = p.Default
+	}
+	if name == "" {
+		return "", &ProfileConfig{}, nil
+	}
+	profile, ok := p.Profiles[name]
+	if !ok {
+		names := []string{}
+		for n := range p.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", nil, xerrors.Errorf("profile %q not found, available profiles: %s", name, strings.Join(names, ", "))
+	}
+	if profile == nil {
+		profile = &ProfileConfig{}
+	}
+	return name, profile, nil
+}
+
+// TransportOptions returns the TLS and proxy settings for the profile.
+func (c *ProfileConfig) TransportOptions() []mdlib.TransportOption {
+	opts := []mdlib.TransportOption{}
+	if c.CAFile != "" {
+		opts = append(opts, mdlib.CACertificatesFile(expandHome(c.CAFile)))
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		opts = append(opts, mdlib.ClientCertificateFiles(expandHome(c.CertFile), expandHome(c.KeyFile)))
+	}
+	if c.Insecure {
+		opts = append(opts, mdlib.InsecureSkipVerify())
+	}
+	if c.Proxy != "" {
+		opts = append(opts, mdlib.Proxy(c.Proxy))
+	}
+	return opts
+}
+
+// Profile will authenticate with the Spinnaker installation from the profile
+// configuration and return an mdlib.Profile suitable for
+// mdcli.NewCommandOptionsFromProfile.  The output function is used to prompt for
+// interactive logins.  The spin config file is rewritten if authenticating
+// updated the stored credentials.
+func (c *ProfileConfig) Profile(name string, output func(string)) (*mdlib.Profile, error) {
+	configFile := DefaultPath()
+	if c.SpinConfig != "" {
+		configFile = expandHome(c.SpinConfig)
+	}
+	cfg, err := Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = cfg.Gate.Endpoint
+	}
+	if baseURL == "" {
+		baseURL = mdlib.DefaultSpinnakerAPIBaseURL
+	}
+
+	// authenticate against the profile base url without persisting it to the
+	// spin config
+	authCfg := *cfg
+	authCfg.Gate.Endpoint = baseURL
+	client, updated, err := HTTPClient(&authCfg, output, c.TransportOptions()...)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create client for profile %q: %w", name, err)
+	}
+	if updated {
+		err = Save(configFile, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	profile := &mdlib.Profile{
+		Name:       name,
+		BaseURL:    baseURL,
+		HTTPClient: client,
+		ConfigDir:  c.ConfigDir,
+		ConfigFile: c.ConfigFile,
+	}
+	if c.Timeout != 0 {
+		profile.ClientOpts = append(profile.ClientOpts, mdlib.WithRequestTimeout(c.Timeout))
+	}
+	return profile, nil
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(homedir.HomeDir(), path[1:])
+	}
+	return path
+}
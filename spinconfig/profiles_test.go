@@ -0,0 +1,107 @@
+package spinconfig
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfilesLookup(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "spinconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(tdir)
+
+	profilesFile := filepath.Join(tdir, "profiles.yml")
+	err = ioutil.WriteFile(profilesFile, []byte(`
+default: test
+profiles:
+  test:
+    baseUrl: https://gate.test.example.com
+  prod:
+    baseUrl: https://gate.prod.example.com
+    file: prod.yml
+    timeout: 30s
+`), 0o644)
+	require.NoError(t, err)
+
+	profiles, err := LoadProfiles(profilesFile)
+	require.NoError(t, err)
+
+	os.Unsetenv(ProfileEnvVar)
+	name, profile, err := profiles.Lookup("")
+	require.NoError(t, err)
+	require.Equal(t, "test", name)
+	require.Equal(t, "https://gate.test.example.com", profile.BaseURL)
+
+	os.Setenv(ProfileEnvVar, "prod")
+	defer os.Unsetenv(ProfileEnvVar)
+	name, profile, err = profiles.Lookup("")
+	require.NoError(t, err)
+	require.Equal(t, "prod", name)
+	require.Equal(t, &ProfileConfig{
+		BaseURL:    "https://gate.prod.example.com",
+		ConfigFile: "prod.yml",
+		Timeout:    30 * time.Second,
+	}, profile)
+
+	name, _, err = profiles.Lookup("test")
+	require.NoError(t, err)
+	require.Equal(t, "test", name)
+
+	_, _, err = profiles.Lookup("staging")
+	require.EqualError(t, err, `profile "staging" not found, available profiles: prod, test`)
+}
+
+func TestProfilesMissingFile(t *testing.T) {
+	profiles, err := LoadProfiles(filepath.Join(os.TempDir(), "does-not-exist", "profiles.yml"))
+	require.NoError(t, err)
+
+	os.Unsetenv(ProfileEnvVar)
+	name, profile, err := profiles.Lookup("")
+	require.NoError(t, err)
+	require.Equal(t, "", name)
+	require.Equal(t, &ProfileConfig{}, profile)
+}
+
+func TestProfileConfigProfile(t *testing.T) {
+	tdir, err := ioutil.TempDir("", "spinconfig")
+	require.NoError(t, err)
+	defer os.RemoveAll(tdir)
+
+	spinConfig := filepath.Join(tdir, "config")
+	err = ioutil.WriteFile(spinConfig, []byte(`
+gate:
+  endpoint: https://gate.spin.example.com
+`), 0o644)
+	require.NoError(t, err)
+
+	// the gate endpoint from the spin config is used by default
+	cfg := &ProfileConfig{SpinConfig: spinConfig, ConfigDir: "deploy", Timeout: time.Minute}
+	profile, err := cfg.Profile("test", func(string) {})
+	require.NoError(t, err)
+	require.Equal(t, "test", profile.Name)
+	require.Equal(t, "https://gate.spin.example.com", profile.BaseURL)
+	require.Equal(t, "deploy", profile.ConfigDir)
+	require.NotNil(t, profile.HTTPClient)
+	require.Len(t, profile.ClientOpts, 1)
+
+	// the profile base url overrides the spin config
+	cfg.BaseURL = "https://gate.test.example.com"
+	profile, err = cfg.Profile("test", func(string) {})
+	require.NoError(t, err)
+	require.Equal(t, "https://gate.test.example.com", profile.BaseURL)
+
+	// the SPINNAKER_API_BASE_URL default is used when neither is set
+	defer func(orig string) { mdlib.DefaultSpinnakerAPIBaseURL = orig }(mdlib.DefaultSpinnakerAPIBaseURL)
+	mdlib.DefaultSpinnakerAPIBaseURL = "https://gate.env.example.com"
+	cfg = &ProfileConfig{SpinConfig: filepath.Join(tdir, "does-not-exist")}
+	profile, err = cfg.Profile("", func(string) {})
+	require.NoError(t, err)
+	require.Equal(t, "https://gate.env.example.com", profile.BaseURL)
+	require.Empty(t, profile.ClientOpts)
+}
@@ -0,0 +1,120 @@
+// Package spinconfig loads the spin CLI configuration (~/.spin/config) and
+// uses it to authenticate mdlib.Client requests to Gate, the same way the
+// spinmd command does.
+package spinconfig
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	mdlib "github.com/spinnaker/md-lib-go"
+	"github.com/spinnaker/spin/cmd/gateclient"
+	"github.com/spinnaker/spin/config"
+	"golang.org/x/xerrors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/client-go/util/homedir"
+)
+
+// DefaultPath returns the location of the spin CLI config file, ~/.spin/config
+func DefaultPath() string {
+	return filepath.Join(homedir.HomeDir(), ".spin", "config")
+}
+
+// Load will read the spin CLI config from configFile.  Environment variables
+// in the file are expanded.  An empty config is returned if the file does not
+// exist.
+func Load(configFile string) (*config.Config, error) {
+	cfg := &config.Config{}
+	yamlFile, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, xerrors.Errorf("unable to read %s: %w", configFile, err)
+	}
+	yamlIn := bytes.NewReader([]byte(os.ExpandEnv(string(yamlFile))))
+	dec := yaml.NewDecoder(yamlIn)
+	dec.KnownFields(true)
+	err = dec.Decode(cfg)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to parse %s as YAML: %w", configFile, err)
+	}
+	return cfg, nil
+}
+
+// Save will write the spin CLI config to configFile.
+func Save(configFile string, cfg *config.Config) error {
+	content, err := yaml.Marshal(cfg)
+	if err != nil {
+		return xerrors.Errorf("failed to marshal config for %s: %w", configFile, err)
+	}
+	err = ioutil.WriteFile(configFile, content, 0o600)
+	if err != nil {
+		return xerrors.Errorf("failed to write %s: %w", configFile, err)
+	}
+	return nil
+}
+
+// HTTPClient will authenticate with Gate using the auth settings from cfg and
+// return a function suitable for mdlib.WithHTTPClient that adds the auth
+// headers to each request.  The output function is used to prompt for
+// interactive logins.  Any TransportOptions are applied to the client used
+// to authenticate and make requests.  If updated is true the cfg was modified
+// with new credentials and should be saved.
+func HTTPClient(cfg *config.Config, output func(string), transportOpts ...mdlib.TransportOption) (client func(*http.Request) (*http.Response, error), updated bool, err error) {
+	httpClient, err := gateclient.InitializeHTTPClient(cfg.Auth)
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to create client: %w", err)
+	}
+	if len(transportOpts) > 0 {
+		httpClient, err = mdlib.ApplyTransportOptions(httpClient, transportOpts...)
+		if err != nil {
+			return nil, false, xerrors.Errorf("failed to configure client: %w", err)
+		}
+	}
+
+	ctx, err := gateclient.ContextWithAuth(context.Background(), cfg.Auth)
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to extract valid login credentials: %w", err)
+	}
+
+	updated, err = gateclient.Authenticate(output, httpClient, cfg.Gate.Endpoint, cfg.Auth)
+	if err != nil {
+		return nil, false, xerrors.Errorf("failed to authenticate with Spinnaker: %w", err)
+	}
+
+	return func(req *http.Request) (*http.Response, error) {
+		gateclient.AddAuthHeaders(ctx, req)
+		return httpClient.Do(req)
+	}, updated, nil
+}
+
+// ClientOpts will load the spin CLI config from configFile and return the
+// mdlib.ClientOpts to reach the configured Gate endpoint with the configured
+// auth.  The config file is rewritten if authenticating updated the stored
+// credentials.
+func ClientOpts(configFile string, output func(string), transportOpts ...mdlib.TransportOption) ([]mdlib.ClientOpt, error) {
+	cfg, err := Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+	client, updated, err := HTTPClient(cfg, output, transportOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if updated {
+		err = Save(configFile, cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+	opts := []mdlib.ClientOpt{mdlib.WithHTTPClient(client)}
+	if cfg.Gate.Endpoint != "" {
+		opts = append(opts, mdlib.WithBaseURL(cfg.Gate.Endpoint))
+	}
+	return opts, nil
+}
@@ -0,0 +1,60 @@
+package mdlib
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// ApplicationSummary describes the managed state of an application in Spinnaker
+type ApplicationSummary struct {
+	ApplicationPaused   bool                 `json:"applicationPaused" yaml:"applicationPaused"`
+	HasManagedResources bool                 `json:"hasManagedResources" yaml:"hasManagedResources"`
+	Resources           []ResourceSummary    `json:"resources" yaml:"resources"`
+	Environments        []EnvironmentSummary `json:"environments" yaml:"environments"`
+}
+
+// ResourceStatus is the management status of a resource, like HAPPY, ACTUATING or DIFF
+type ResourceStatus string
+
+// ResourceSummary describes the management status of a resource
+type ResourceSummary struct {
+	ID        string                    `json:"id" yaml:"id"`
+	Kind      string                    `json:"kind" yaml:"kind"`
+	Status    ResourceStatus            `json:"status" yaml:"status"`
+	Moniker   Moniker                   `json:"moniker" yaml:"moniker"`
+	Locations DeliveryResourceLocations `json:"locations" yaml:"locations"`
+}
+
+// EnvironmentSummary describes an environment and the ids of its resources
+type EnvironmentSummary struct {
+	Name      string   `json:"name" yaml:"name"`
+	Resources []string `json:"resources" yaml:"resources"`
+}
+
+// Resource returns the summary for the resource id.
+func (s *ApplicationSummary) Resource(id string) (ResourceSummary, bool) {
+	for _, resource := range s.Resources {
+		if resource.ID == id {
+			return resource, true
+		}
+	}
+	return ResourceSummary{}, false
+}
+
+// GetApplicationSummary returns the managed state of the application, including the
+// environments and resource statuses known to Spinnaker.
+func GetApplicationSummary(cli *Client, appName string) (*ApplicationSummary, error) {
+	return GetApplicationSummaryContext(context.Background(), cli, appName)
+}
+
+// GetApplicationSummaryContext is like GetApplicationSummary but the request is bound to ctx.
+func GetApplicationSummaryContext(ctx context.Context, cli *Client, appName string) (*ApplicationSummary, error) {
+	summary := &ApplicationSummary{}
+	u := fmt.Sprintf("/managed/application/%s?entities=resources,environments", url.PathEscape(appName))
+	err := commonParsedGet(ctx, cli, u, summary)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,143 @@
+package mdlib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// TransportOption is used to configure the http.Transport used to reach the Spinnaker API.
+type TransportOption func(t *http.Transport) error
+
+// WithTransport is a ClientOpt to apply TransportOptions to the default transport via NewClient.
+// It cannot be combined with WithHTTPClient, in that case the TransportOptions should be applied to
+// the custom client via ApplyTransportOptions.  Any errors from the TransportOptions, or from combining
+// them with WithHTTPClient, will be returned on the first request made with the Client.
+func WithTransport(opts ...TransportOption) ClientOpt {
+	return func(c *Client) {
+		c.transportOpts = append(c.transportOpts, opts...)
+	}
+}
+
+// ApplyTransportOptions will apply the TransportOptions to a copy of client, the client
+// Transport must be nil or an *http.Transport.
+func ApplyTransportOptions(client *http.Client, opts ...TransportOption) (*http.Client, error) {
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, xerrors.Errorf("cannot configure unexpected transport type %T", client.Transport)
+	}
+	for _, opt := range opts {
+		if err := opt(transport); err != nil {
+			return nil, err
+		}
+	}
+	updated := *client
+	updated.Transport = transport
+	return &updated, nil
+}
+
+func tlsConfig(t *http.Transport) *tls.Config {
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	return t.TLSClientConfig
+}
+
+// CACertificates is a TransportOption to trust the PEM encoded CA certificates in addition to
+// the system CA certificates.
+func CACertificates(pemCerts []byte) TransportOption {
+	return func(t *http.Transport) error {
+		cfg := tlsConfig(t)
+		if cfg.RootCAs == nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			cfg.RootCAs = pool
+		}
+		if !cfg.RootCAs.AppendCertsFromPEM(pemCerts) {
+			return xerrors.New("no valid PEM encoded CA certificates found")
+		}
+		return nil
+	}
+}
+
+// CACertificatesFile is like CACertificates but loads the CA bundle from disk.
+func CACertificatesFile(caFile string) TransportOption {
+	return func(t *http.Transport) error {
+		pemCerts, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return xerrors.Errorf("failed to read CA bundle %s: %w", caFile, err)
+		}
+		if err := CACertificates(pemCerts)(t); err != nil {
+			return xerrors.Errorf("failed to load CA bundle %s: %w", caFile, err)
+		}
+		return nil
+	}
+}
+
+// ClientCertificate is a TransportOption to authenticate with an x509 client certificate.
+func ClientCertificate(cert tls.Certificate) TransportOption {
+	return func(t *http.Transport) error {
+		cfg := tlsConfig(t)
+		cfg.Certificates = append(cfg.Certificates, cert)
+		return nil
+	}
+}
+
+// ClientCertificateFiles is like ClientCertificate but loads the PEM encoded certificate and
+// key from disk.
+func ClientCertificateFiles(certFile, keyFile string) TransportOption {
+	return func(t *http.Transport) error {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return xerrors.Errorf("failed to load client certificate %s: %w", certFile, err)
+		}
+		return ClientCertificate(cert)(t)
+	}
+}
+
+// InsecureSkipVerify is a TransportOption to disable verification of the Spinnaker API server
+// certificate.  This makes connections vulnerable to man-in-the-middle attacks and should only
+// be used for testing.
+func InsecureSkipVerify() TransportOption {
+	return func(t *http.Transport) error {
+		tlsConfig(t).InsecureSkipVerify = true
+		return nil
+	}
+}
+
+// Proxy is a TransportOption to send all requests via the proxy, overriding the
+// HTTP_PROXY/HTTPS_PROXY environment variables.  An empty proxyURL will disable proxying.
+func Proxy(proxyURL string) TransportOption {
+	return func(t *http.Transport) error {
+		if proxyURL == "" {
+			t.Proxy = nil
+			return nil
+		}
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return xerrors.Errorf("failed to parse proxy url %q: %w", proxyURL, err)
+		}
+		t.Proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// WithRequestTimeout is a ClientOpt to limit how long each attempt of a request to the
+// Spinnaker API may take, including reading the response body.
+func WithRequestTimeout(timeout time.Duration) ClientOpt {
+	return func(c *Client) {
+		c.requestTimeout = timeout
+	}
+}
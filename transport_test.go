@@ -0,0 +1,67 @@
+package mdlib
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportCACertificates(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`[{"name": "myapp-v001", "account": "test"}]`))
+			},
+		),
+	)
+	defer ts.Close()
+
+	// server certificate is not trusted by default
+	cli := NewClient(WithBaseURL(ts.URL), WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+	serverGroups := []ServerGroup{}
+	err := GetServerGroups(cli, "myapp", &serverGroups)
+	require.Error(t, err)
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	cli = NewClient(WithBaseURL(ts.URL), WithTransport(CACertificates(caPEM)))
+	err = GetServerGroups(cli, "myapp", &serverGroups)
+	require.NoError(t, err)
+	require.Equal(t, []ServerGroup{{Name: "myapp-v001", Account: "test"}}, serverGroups)
+}
+
+func TestTransportOptionError(t *testing.T) {
+	cli := NewClient(WithBaseURL("https://localhost"), WithTransport(CACertificates([]byte("bogus"))))
+	err := GetServerGroups(cli, "myapp", &[]ServerGroup{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no valid PEM encoded CA certificates found")
+}
+
+func TestRequestTimeout(t *testing.T) {
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+		),
+	)
+	defer ts.Close()
+
+	cli := NewClient(
+		WithBaseURL(ts.URL),
+		WithRequestTimeout(50*time.Millisecond),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}),
+	)
+	err := GetServerGroups(cli, "myapp", &[]ServerGroup{})
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "expected deadline exceeded, got %s", err)
+}
@@ -0,0 +1,95 @@
+package mdlib
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// VerificationStatus is the outcome of a verification for an artifact version
+type VerificationStatus string
+
+const (
+	// VerificationNotEvaluated is the status of a verification that has not started
+	VerificationNotEvaluated VerificationStatus = "NOT_EVALUATED"
+	// VerificationPending is the status of a verification that is running
+	VerificationPending VerificationStatus = "PENDING"
+	// VerificationPass is the status of a verification that succeeded
+	VerificationPass VerificationStatus = "PASS"
+	// VerificationFail is the status of a verification that failed
+	VerificationFail VerificationStatus = "FAIL"
+	// VerificationOverridePass is the status of a verification that was manually passed
+	VerificationOverridePass VerificationStatus = "OVERRIDE_PASS"
+	// VerificationOverrideFail is the status of a verification that was manually failed
+	VerificationOverrideFail VerificationStatus = "OVERRIDE_FAIL"
+)
+
+// Passed returns true if the verification succeeded or was manually passed
+func (s VerificationStatus) Passed() bool {
+	return s == VerificationPass || s == VerificationOverridePass
+}
+
+// Failed returns true if the verification failed or was manually failed
+func (s VerificationStatus) Failed() bool {
+	return s == VerificationFail || s == VerificationOverrideFail
+}
+
+// VerificationState is the state of a verification, from the verifyWith entries of an
+// environment, for an artifact version deployed to the environment.
+type VerificationState struct {
+	EnvironmentName   string             `json:"environmentName" yaml:"environmentName"`
+	ArtifactReference string             `json:"artifactReference" yaml:"artifactReference"`
+	ArtifactVersion   string             `json:"artifactVersion" yaml:"artifactVersion"`
+	ID                string             `json:"id" yaml:"id"`
+	Type              string             `json:"type" yaml:"type"`
+	Status            VerificationStatus `json:"status" yaml:"status"`
+	StartedAt         *time.Time         `json:"startedAt,omitempty" yaml:"startedAt,omitempty"`
+	EndedAt           *time.Time         `json:"endedAt,omitempty" yaml:"endedAt,omitempty"`
+	// Link is the url for the details of the verification run, like a test
+	// report or container job.
+	Link     string                 `json:"link,omitempty" yaml:"link,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// String returns the verification as "type for reference version in environment"
+func (s VerificationState) String() string {
+	return fmt.Sprintf("%s for %s %s in %s", s.Type, s.ArtifactReference, s.ArtifactVersion, s.EnvironmentName)
+}
+
+// GetVerificationStates returns the verification states for the environment envName, for
+// all artifact versions that have been deployed to it.
+func GetVerificationStates(cli *Client, appName, envName string) ([]VerificationState, error) {
+	return GetVerificationStatesContext(context.Background(), cli, appName, envName)
+}
+
+// GetVerificationStatesContext is like GetVerificationStates but the request is bound to ctx.
+func GetVerificationStatesContext(ctx context.Context, cli *Client, appName, envName string) ([]VerificationState, error) {
+	states := []VerificationState{}
+	u := fmt.Sprintf("/managed/application/%s/environment/%s/verifications", url.PathEscape(appName), url.PathEscape(envName))
+	err := commonParsedGet(ctx, cli, u, &states)
+	if err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+// EnvironmentVerifications returns the verification states for the environments envNames.
+func EnvironmentVerifications(cli *Client, appName string, envNames []string) ([]VerificationState, error) {
+	return EnvironmentVerificationsContext(context.Background(), cli, appName, envNames)
+}
+
+// EnvironmentVerificationsContext is like EnvironmentVerifications but the requests are bound to ctx.
+func EnvironmentVerificationsContext(ctx context.Context, cli *Client, appName string, envNames []string) ([]VerificationState, error) {
+	verifications := []VerificationState{}
+	for _, envName := range envNames {
+		states, err := GetVerificationStatesContext(ctx, cli, appName, envName)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to get verifications for environment %s: %w", envName, err)
+		}
+		verifications = append(verifications, states...)
+	}
+	return verifications, nil
+}